@@ -1,118 +1,425 @@
 package cmd
 
-/*import (
+import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/danhale-git/runrdp/internal/config"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-// configureCmd represents the configure command
-var configureCmd = &cobra.Command{
-	Use:       "configure",
-	Short:     "TBD",
-	Long:      `TBD`,
-	ValidArgs: []string{"add", "show"},
-	Args: func(cmd *cobra.Command, args []string) error {
-		if err := cobra.OnlyValidArgs(cmd, args); err != nil {
-			return err
-		}
+func configureCommand() *cobra.Command {
+	// configureCmd represents the configure command
+	command := &cobra.Command{
+		Use:   "configure",
+		Short: "List, show, add, edit, rename and remove config entries",
+		Long: `Manage config entries without editing config files by hand. Entries are edited in the file they are defined
+in, leaving the comments and formatting of other entries unchanged.
 
-		if len(args) == 0 {
-			return nil
-		}
+Entries may be referred to by their name or their full key, for example 'myhost' or 'host.awsec2.myhost'. Field values
+are given as field=value pairs. Array values are comma separated.
 
-		switch args[0] {
-		case "add":
-		//stuff
-		case "show":
-			cmd.MarkFlagRequired("name")
-		}
-		return nil
-	},
-	Run: func(cmd *cobra.Command, args []string) {
-		// Check config directory exists
-		if !checkDefaultConfig() {
-			return
-		}
+References to entries which don't exist are printed as warnings rather than stopping the command, so they can be
+fixed with configure.`,
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			loadConfiguration(false)
+		},
+	}
 
-		// Limit operations to specific file name if given
-		if viper.IsSet("file") {
-			f := viper.GetString("file")
-			path := filepath.Join(viper.GetString("config-root"), f)
+	command.AddCommand(
+		configureListCommand(),
+		configureShowCommand(),
+		configureAddCommand(),
+		configureEditCommand(),
+		configureRemoveCommand(),
+		configureRenameCommand(),
+	)
 
-			if !CheckExistence(
-				path,
-				fmt.Sprintf("%s config file", f),
-				false,
-			) {
-				return
+	return command
+}
+
+func configureListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:       "list [host|cred|template|settings|tunnel|group]...",
+		Short:     "List config entries by type",
+		ValidArgs: config.EntryKinds,
+		Args:      cobra.OnlyValidArgs,
+		Run: func(_ *cobra.Command, args []string) {
+			if len(args) == 0 {
+				args = config.EntryKinds
+			}
+
+			byKind := make(map[string][]string)
+			for _, file := range sortedConfigFiles() {
+				for _, key := range config.EntryKeys(configFiles[file]) {
+					kind := config.Entry{Key: key}.Kind()
+					byKind[kind] = append(byKind[kind], fmt.Sprintf("  %-40s %s", key, relativeConfigPath(file)))
+				}
 			}
-			configuration.Data = map[string]*viper.Viper{f: configuration.Data[f]}
-		}
 
-		// No arguments given
-		if len(args) == 0 {
-			// List config entries
-			if viper.GetBool("list") {
-				fmt.Println("\nHosts:")
-				for _, c := range configuration.Data {
-					for kind, names := range c.GetStringMap("host") {
-						for name := range names.(map[string]interface{}) {
-							fmt.Printf("  %s (%s)", name, kind)
-						}
-						fmt.Println()
-					}
+			for _, kind := range args {
+				fmt.Printf("%s:\n", kind)
+				for _, line := range byKind[kind] {
+					fmt.Println(line)
 				}
+			}
+		},
+	}
+}
+
+func configureShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show the parsed fields of a config entry",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			key, file := findEntry(args[0])
+
+			fields, ok := configuration.EntryFields(key)
+			if !ok {
+				log.Fatalf("entry %s was not loaded", key)
+			}
+
+			fmt.Printf("# %s\n", relativeConfigPath(file))
+			fmt.Print(config.Entry{Key: key, Fields: fields}.TOML())
+		},
+	}
+}
+
+func configureAddCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "add <key> [field=value]...",
+		Short: "Add a config entry",
+		Long: `Add a config entry with the given key and fields. The key must be one of host.<type>.<name>,
+cred.<type>.<name>, template.<type>.<name>, settings.<name>, tunnel.<name> or group.<name>. The entry is validated
+before it is written.`,
+		Example: `  runrdp configure add host.awsec2.myhost id=i-abcde1234 region=eu-west-2 private=true
+  runrdp configure add settings.small width=800 height=600`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			key := strings.ToLower(args[0])
+
+			fields, err := parseFieldArgs(key, args[1:])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if err := config.ValidateEntry(key, fields); err != nil {
+				log.Fatalf("invalid entry: %s", err)
+			}
+
+			entry := config.Entry{Key: key, Fields: fields}
+			if err := configuration.CheckNewEntries([]config.Entry{entry}); err != nil {
+				log.Fatal(err)
+			}
+
+			name, _ := cmd.Flags().GetString("file")
+			path := filepath.Join(viper.GetString("config-root"), name)
+
+			if !CheckExistence(viper.GetString("config-root"), "config directory", true) {
 				return
 			}
 
-			return
+			f, err := readConfigFile(path, true)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			f.AppendEntry(entry)
+
+			writeConfigFile(path, f)
+			fmt.Printf("added %s to %s\n", key, relativeConfigPath(path))
+		},
+	}
+
+	command.Flags().String("file", config.DefaultConfigName, "Name of the file in the config root to add the entry to")
+
+	return command
+}
+
+func configureEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit <name> field=value...",
+		Short: "Set or remove fields in a config entry",
+		Long: `Set the given fields in a config entry, replacing any existing values. A field with an empty value
+(field=) is removed. The entry is validated before it is written.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			key, path := findEntry(args[0])
+
+			changes, err := parseFieldArgs(key, args[1:])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			f, err := readConfigFile(path, false)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			// Validate the fields in the file, configFiles has the active environment applied
+			fields, err := fileEntryFields(f, key)
+			if err != nil {
+				log.Fatalf("%s: %s", relativeConfigPath(path), err)
+			}
+
+			for k, v := range changes {
+				if v == "" {
+					delete(fields, k)
+					continue
+				}
+
+				fields[k] = v
+			}
+
+			if err := config.ValidateEntry(key, fields); err != nil {
+				log.Fatalf("invalid entry: %s", err)
+			}
+
+			for k, v := range changes {
+				if v == "" {
+					err = f.RemoveField(key, k)
+				} else {
+					err = f.SetField(key, k, v)
+				}
+
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			writeConfigFile(path, f)
+			fmt.Printf("updated %s in %s\n", key, relativeConfigPath(path))
+		},
+	}
+}
+
+func configureRemoveCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a config entry",
+		Long: `Remove a config entry. Entries which other entries refer to are not removed unless --force is given,
+because the references would no longer resolve.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, path := findEntry(args[0])
+
+			force, _ := cmd.Flags().GetBool("force")
+			refuseReferenced(key, force)
+
+			f, err := readConfigFile(path, false)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if err := f.RemoveEntry(key); err != nil {
+				log.Fatal(err)
+			}
+
+			writeConfigFile(path, f)
+			fmt.Printf("removed %s from %s\n", key, relativeConfigPath(path))
+
+			warnReferences(key)
+		},
+	}
+
+	command.Flags().Bool("force", false, "Remove the entry even if other entries refer to it")
+
+	return command
+}
+
+func configureRenameCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "rename <name> <new name>",
+		Short: "Rename a config entry",
+		Long: `Rename a config entry. Entries which other entries refer to are not renamed unless --force is given, because
+the references would no longer resolve.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, path := findEntry(args[0])
+
+			force, _ := cmd.Flags().GetBool("force")
+			refuseReferenced(key, force)
+
+			parts := strings.Split(key, ".")
+			parts[len(parts)-1] = strings.ToLower(args[1])
+			newKey := strings.Join(parts, ".")
+
+			if err := config.ValidateEntryName(parts[len(parts)-1]); err != nil {
+				log.Fatal(err)
+			}
+
+			if err := configuration.CheckNewEntries([]config.Entry{{Key: newKey}}); err != nil {
+				log.Fatal(err)
+			}
+
+			f, err := readConfigFile(path, false)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if err := f.RenameEntry(key, newKey); err != nil {
+				log.Fatal(err)
+			}
+
+			writeConfigFile(path, f)
+			fmt.Printf("renamed %s to %s in %s\n", key, newKey, relativeConfigPath(path))
+
+			warnReferences(key)
+		},
+	}
+
+	command.Flags().Bool("force", false, "Rename the entry even if other entries refer to it")
+
+	return command
+}
+
+// findEntry returns the key of the entry with the given name or key, and the path of the file it is defined in.
+func findEntry(name string) (string, string) {
+	name = strings.ToLower(name)
+	matches := make(map[string]string)
+
+	for file, v := range configFiles {
+		for _, key := range config.EntryKeys(v) {
+			if key == name || (config.Entry{Key: key}).Name() == name {
+				matches[key] = file
+			}
 		}
+	}
+
+	switch len(matches) {
+	case 0:
+		log.Fatalf("config entry %s does not exist", name)
+	case 1:
+		for k, f := range matches {
+			return k, f
+		}
+	}
+
+	keys := make([]string, 0, len(matches))
+	for k := range matches {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	log.Fatalf("%s matches more than one entry, use one of: %s", name, strings.Join(keys, ", "))
+
+	return "", ""
+}
 
-		switch args[0] {
-		case "add":
+// parseFieldArgs parses field=value arguments to the types of the fields in the entry with the given key. Empty values
+// are returned as empty strings.
+func parseFieldArgs(key string, args []string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
 
+	for _, a := range args {
+		parts := strings.SplitN(a, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected field=value: got '%s'", a)
+		}
+
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
 
+		if parts[1] == "" {
+			fields[field] = ""
+			continue
+		}
 
-		case "show":
-			//name := viper.GetString("name")
-			//fmt.Println(configuration.Get(name))
-		default:
+		v, err := config.ParseFieldValue(key, field, parts[1])
+		if err != nil {
+			return nil, err
 		}
-	},
+
+		fields[field] = v
+	}
+
+	return fields, nil
 }
 
-func checkDefaultConfig() bool {
-	configRoot := viper.GetString("config-root")
-	if !CheckExistence(
-		configRoot,
-		"config directory",
-		true,
-	) {
-		return false
+// refuseReferenced exits if other entries refer to the entry with the given key by name, unless force is true.
+func refuseReferenced(key string, force bool) {
+	refs := configuration.ReferencesTo(key)
+	if len(refs) == 0 || force {
+		return
 	}
 
-	return CheckExistence(
-		filepath.Join(configRoot, config.DefaultConfigName),
-		"default config file",
-		false,
-	)
+	log.Fatalf("%s is still used, change these references first or use --force:\n  %s", key,
+		strings.Join(refs, "\n  "))
+}
+
+// warnReferences prints the entries which refer to the entry with the given key by name.
+func warnReferences(key string) {
+	for _, ref := range configuration.ReferencesTo(key) {
+		fmt.Printf("WARNING: %s\n", ref)
+	}
+}
+
+func readConfigFile(path string, create bool) (*config.File, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && create {
+		return config.NewFile(""), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	return config.NewFile(string(data)), nil
+}
+
+// fileEntryFields returns the fields of the entry with the given key as they are written in f.
+func fileEntryFields(f *config.File, key string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+
+	if err := v.ReadConfig(strings.NewReader(f.String())); err != nil {
+		return nil, err
+	}
+
+	if !v.IsSet(key) {
+		return nil, fmt.Errorf("%s is not defined in this file, it may be added by the active environment", key)
+	}
+
+	return v.GetStringMap(key), nil
 }
 
-func init() {
-	rootCmd.AddCommand(configureCmd)
+func writeConfigFile(path string, f *config.File) {
+	if err := f.Check(); err != nil {
+		log.Fatalf("%s was not changed: %s", relativeConfigPath(path), err)
+	}
 
-	configureCmd.Flags().BoolP("list", "l", false, "list config entries")
-	configureCmd.Flags().StringP("name", "n", "", "full name of the config entry to operate on")
-	configureCmd.Flags().StringP("file", "f", "", "name of the config file to operate on")
+	if err := ioutil.WriteFile(path, []byte(f.String()), 0600); err != nil {
+		log.Fatalf("writing config file: %s", err)
+	}
+}
 
-	viper.BindPFlags(configureCmd.Flags())
+func sortedConfigFiles() []string {
+	files := make([]string, 0, len(configFiles))
+	for f := range configFiles {
+		files = append(files, f)
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// relativeConfigPath returns path relative to the config root if possible.
+func relativeConfigPath(path string) string {
+	rel, err := filepath.Rel(viper.GetString("config-root"), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
 }
 
 // CheckExistence checks for the existence of a file or directory, prompts the user to create it and returns true if
@@ -153,4 +460,4 @@ func interactiveYesNo() bool {
 	text, _ := reader.ReadString('\n')
 
 	return strings.TrimSpace(strings.ToLower(text)) == "y"
-}*/
+}
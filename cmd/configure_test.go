@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config"
+)
+
+func TestFileEntryFields(t *testing.T) {
+	f := config.NewFile(`
+[settings.small]
+    width = 800
+
+[env.prod.settings.small]
+    width = 100`)
+
+	fields, err := fileEntryFields(f, "settings.small")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if fields["width"] != int64(800) {
+		t.Errorf("unexpected width: want 800: got %v", fields["width"])
+	}
+
+	if _, err := fileEntryFields(f, "settings.large"); err == nil {
+		t.Errorf("no error returned for an entry which isn't in the file")
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config/creds"
+	"github.com/danhale-git/runrdp/internal/config/creds/keyring"
+
+	"github.com/spf13/cobra"
+)
+
+func credCommand() *cobra.Command {
+	// credCmd represents the cred command
+	command := &cobra.Command{
+		Use:   "cred",
+		Short: "Store and forget passwords for keyring creds",
+		Long: `Store and forget the passwords of cred.keyring entries in the OS secret store: the Secret Service on Linux,
+Keychain on macOS and Credential Manager on Windows.`,
+	}
+
+	command.AddCommand(
+		credSetCommand(),
+		credForgetCommand(),
+	)
+
+	return command
+}
+
+func credSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name>",
+		Short: "Prompt for a password and store it for a keyring cred",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			name := strings.ToLower(args[0])
+			k := keyringCred(name)
+
+			password, err := creds.PromptPassword(fmt.Sprintf("Password for %s: ", name), true)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if err := k.Store(password); err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("stored password for %s\n", name)
+		},
+	}
+}
+
+func credForgetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "forget <name>",
+		Short: "Remove the stored password of a keyring cred",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			name := strings.ToLower(args[0])
+
+			err := keyringCred(name).Forget()
+			if errors.Is(err, keyring.ErrNotFound) {
+				fmt.Printf("no password is stored for %s\n", name)
+				return
+			} else if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("removed password for %s\n", name)
+		},
+	}
+}
+
+// keyringCred returns the cred with the given name, exiting if it doesn't exist or is not a keyring cred.
+func keyringCred(name string) *creds.Keyring {
+	c, ok := configuration.Creds[name]
+	if !ok {
+		log.Fatalf("cred %s does not exist in config", name)
+	}
+
+	k, ok := c.(*creds.Keyring)
+	if !ok {
+		log.Fatalf("cred %s is of type %s, passwords can only be stored for keyring creds", name,
+			configuration.CredType(name))
+	}
+
+	return k
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	envVariable   = "RUNRDP_ENV" // Environment variable which selects the active environment
+	activeEnvFile = "active-env" // File in the config root which contains the environment set with 'runrdp env use'
+)
+
+func envCommand() *cobra.Command {
+	// envCmd represents the env command
+	command := &cobra.Command{
+		Use:   "env",
+		Short: "List environments and set the active environment",
+		Long: fmt.Sprintf(`Environments are alternative sets of config, for example for dev and prod accounts. An environment is
+defined by a directory of config files in %s/<name> in the config root, which are loaded as well as the
+other config files, and/or [env.<name>] tables which override fields in other entries.
+
+The active environment is chosen with --env, then %s, then the environment set with 'runrdp env use'.`,
+			config.EnvDirectory, envVariable),
+		// Override the root command so the config is read without applying an environment, which may not exist, and
+		// without parsing it, so broken references never stop environments being listed or changed.
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			debug = viper.GetBool("debug")
+
+			var err error
+			configFiles, err = readAllConfigs(viper.GetString("config-root"), ".toml")
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	command.AddCommand(
+		envListCommand(),
+		envUseCommand(),
+	)
+
+	return command
+}
+
+func envListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List environments, marking the active environment with *",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			active := activeEnvironment()
+			overlays := config.Environments(configFiles)
+
+			for _, name := range environmentNames() {
+				sources := make([]string, 0)
+				if dir, ok := envDirectory(name); ok {
+					sources = append(sources, filepath.Join(config.EnvDirectory, filepath.Base(dir)))
+				}
+				if containsString(overlays, name) {
+					sources = append(sources, fmt.Sprintf("[%s.%s]", config.EnvKey, name))
+				}
+
+				marker := " "
+				if name == active {
+					marker = "*"
+				}
+
+				fmt.Printf("%s %-20s %s\n", marker, name, strings.Join(sources, ", "))
+			}
+		},
+	}
+}
+
+func envUseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use [name]",
+		Short: "Set the active environment, or clear it if no name is given",
+		Args:  cobra.RangeArgs(0, 1),
+		Run: func(_ *cobra.Command, args []string) {
+			path := filepath.Join(viper.GetString("config-root"), activeEnvFile)
+
+			if len(args) == 0 {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					log.Fatal(err)
+				}
+
+				fmt.Println("cleared the active environment")
+				return
+			}
+
+			name := strings.ToLower(args[0])
+			if !containsString(environmentNames(), name) {
+				log.Fatalf("environment %s does not exist, must be one of: %s", name,
+					strings.Join(environmentNames(), ", "))
+			}
+
+			if err := ioutil.WriteFile(path, []byte(name+"\n"), 0600); err != nil {
+				log.Fatalf("saving active environment: %s", err)
+			}
+
+			fmt.Printf("active environment is %s\n", name)
+
+			if e := viper.GetString("env"); e != "" && strings.ToLower(e) != name {
+				fmt.Printf("WARNING: %s is set to %s, which takes precedence\n", envVariable, e)
+			}
+		},
+	}
+}
+
+// activeEnvironment returns the name of the environment selected with --env or RUNRDP_ENV, or the environment set
+// with 'runrdp env use'. An empty string is returned if no environment is selected.
+func activeEnvironment() string {
+	if e := viper.GetString("env"); e != "" {
+		return strings.ToLower(e)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(viper.GetString("config-root"), activeEnvFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Println("error reading active environment:", err)
+		}
+
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// loadEnvironment adds the config files in the directory of the named environment to files and applies its overlays.
+// Entries in the environment directory replace entries with the same kind and name in files.
+func loadEnvironment(files map[string]*viper.Viper, name string) (map[string]*viper.Viper, error) {
+	if !containsString(environmentNames(), name) {
+		return nil, fmt.Errorf("environment does not exist, must be one of: %s",
+			strings.Join(environmentNames(), ", "))
+	}
+
+	if dir, ok := envDirectory(name); ok {
+		envFiles, err := readAllConfigs(dir, ".toml")
+		if err != nil {
+			return nil, err
+		}
+
+		if files, err = config.ReplaceEntries(files, envFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	return config.ApplyEnvironment(files, name)
+}
+
+// environmentNames returns the names of all environments which have a directory or an overlay in configFiles.
+func environmentNames() []string {
+	names := config.Environments(configFiles)
+
+	for _, name := range envDirectoryNames() {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// envDirectoryNames returns the names of the environments which have a directory of config files. Names are lower
+// case, like the names of environments in [env.<name>] tables.
+func envDirectoryNames() []string {
+	names := make([]string, 0)
+
+	infos, err := ioutil.ReadDir(filepath.Join(viper.GetString("config-root"), config.EnvDirectory))
+	if err != nil {
+		return names
+	}
+
+	for _, info := range infos {
+		if name := strings.ToLower(info.Name()); info.IsDir() && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// envDirectory returns the path of the directory of config files of the named environment, ignoring case, and true if
+// it exists.
+func envDirectory(name string) (string, bool) {
+	root := filepath.Join(viper.GetString("config-root"), config.EnvDirectory)
+
+	infos, err := ioutil.ReadDir(root)
+	if err != nil {
+		return "", false
+	}
+
+	for _, info := range infos {
+		if info.IsDir() && strings.EqualFold(info.Name(), name) {
+			return filepath.Join(root, info.Name()), true
+		}
+	}
+
+	return "", false
+}
+
+func containsString(s []string, v string) bool {
+	for _, item := range s {
+		if item == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config"
+	"github.com/spf13/viper"
+)
+
+func TestLoadEnvironment(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, config.EnvDirectory, "Prod")
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, body := range map[string]string{
+		filepath.Join(root, "config.toml"): `
+[host.basic.web]
+    address = "10.0.0.1"`,
+		filepath.Join(dir, "web.toml"): `
+[host.basic.web]
+    address = "10.0.0.2"`,
+	} {
+		if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	viper.Set("config-root", root)
+	t.Cleanup(func() { viper.Set("config-root", "") })
+
+	files, err := readAllConfigs(root, ".toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := configFiles
+	configFiles = files
+	t.Cleanup(func() { configFiles = old })
+
+	// Directory names are matched ignoring case, like environment names
+	if names := environmentNames(); len(names) != 1 || names[0] != "prod" {
+		t.Errorf("unexpected environment names: want [prod]: got %v", names)
+	}
+
+	envFiles, err := loadEnvironment(files, "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c, err := config.New(envFiles, true)
+	if err != nil {
+		t.Fatalf("unexpected error parsing environment: %s", err)
+	}
+
+	if got := c.HostGlobals["web"]["address"]; got != "10.0.0.2" {
+		t.Errorf("web was not replaced by the environment: want address '10.0.0.2': got '%s'", got)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+	"github.com/danhale-git/runrdp/internal/rdp"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func exportCommand() *cobra.Command {
+	// exportCmd represents the export command
+	command := &cobra.Command{
+		Use:   "export [host]",
+		Short: "Export a configured host as a .rdp file",
+		Long: `Export a configured host as a standalone .rdp file using its address and settings. Passwords are never
+written. Use --all to export every host to a directory.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all, _ := cmd.Flags().GetBool("all"); all {
+				return cobra.NoArgs(cmd, args)
+			}
+
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+
+			if all, _ := cmd.Flags().GetBool("all"); all {
+				exportAllHosts(output)
+				return
+			}
+
+			host := strings.ToLower(args[0])
+			if !configuration.HostExists(host) {
+				log.Fatalf("host %s does not exist in config", host)
+			}
+
+			if output == "" {
+				output = host + ".rdp"
+			}
+
+			if err := exportHost(host, output); err != nil {
+				log.Fatalf("exporting %s: %s", host, err)
+			}
+
+			fmt.Printf("exported %s to %s\n", host, output)
+		},
+	}
+
+	command.Flags().StringP("output", "o", "",
+		"File to write, or directory to write to with --all. Defaults to <host>.rdp or the working directory")
+	command.Flags().BoolP("all", "a", false, "Export all hosts to a directory")
+
+	return command
+}
+
+// exportAllHosts writes a .rdp file for every host to the given directory. Hosts which can't be exported are reported
+// and skipped.
+func exportAllHosts(directory string) {
+	if directory == "" {
+		directory = "."
+	}
+
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		log.Fatalf("creating export directory: %s", err)
+	}
+
+	keys := configuration.HostKeys()
+	sort.Strings(keys)
+
+	exported := 0
+	for _, host := range keys {
+		if err := exportHost(host, filepath.Join(directory, host+".rdp")); err != nil {
+			fmt.Printf("error exporting %s: %s\n", host, err)
+			continue
+		}
+
+		exported++
+	}
+
+	fmt.Printf("exported %d of %d hosts to %s\n", exported, len(keys), directory)
+}
+
+// exportHost writes the .rdp file for the given host to path. The username is taken from the global username field
+// or the --username flag and the password is never written.
+func exportHost(host, path string) error {
+	address, port, err := configuration.HostSocket(host, false)
+	if err != nil {
+		return fmt.Errorf("getting host socket: %w", err)
+	}
+
+	if viper.GetString("address") != "" {
+		address = viper.GetString("address")
+	}
+	if viper.GetString("port") != "" {
+		port = viper.GetString("port")
+	}
+
+	if address == "" {
+		return fmt.Errorf("host has no address")
+	}
+
+	username := configuration.HostGlobals[host][hosts.GlobalUsername.String()]
+	if viper.GetString("username") != "" {
+		username = viper.GetString("username")
+	}
+
+	if t := configuration.HostGlobals[host][hosts.GlobalTunnel.String()]; t != "" {
+		fmt.Printf("%s: ignoring ssh tunnel '%s', the exported file connects directly to %s\n", host, t, address)
+	}
+
+	settings := getSettings(host)
+	params := sessionParams(&settings, username, "", address, port)
+
+	return ioutil.WriteFile(path, []byte(rdp.FileBody(&params)), 0644)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config"
+	"github.com/spf13/viper"
+)
+
+// setConfiguration replaces the global configuration with one parsed from the given TOML for the duration of the test.
+func setConfiguration(t *testing.T, toml string) {
+	t.Helper()
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(bytes.NewBufferString(toml)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := config.New(map[string]*viper.Viper{"test": v}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := configuration
+	configuration = c
+	t.Cleanup(func() { configuration = old })
+}
+
+const exportTestConfig = `
+[host.basic.web]
+    address = "10.0.0.1"
+    port = "3390"
+    username = "admin"
+    settings = "small"
+
+[host.basic.noaddress]
+
+[settings.small]
+    width = 800
+    height = 600`
+
+func TestExportHost(t *testing.T) {
+	setConfiguration(t, exportTestConfig)
+
+	path := filepath.Join(t.TempDir(), "web.rdp")
+	if err := exportHost("web", path); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"full address:s:10.0.0.1:3390",
+		"username:s:admin",
+		"desktopwidth:i:800",
+		"desktopheight:i:600",
+	} {
+		if !strings.Contains(string(b), line) {
+			t.Errorf("exported file does not contain '%s':\n%s", line, b)
+		}
+	}
+
+	if err := exportHost("noaddress", filepath.Join(t.TempDir(), "noaddress.rdp")); err == nil {
+		t.Errorf("expected an error exporting a host with no address")
+	}
+}
+
+func TestExportAllHosts(t *testing.T) {
+	setConfiguration(t, exportTestConfig)
+
+	directory := filepath.Join(t.TempDir(), "export")
+	exportAllHosts(directory)
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The host without an address is skipped
+	if len(files) != 1 || files[0].Name() != "web.rdp" {
+		t.Errorf("expected only web.rdp to be exported: got %v", files)
+	}
+}
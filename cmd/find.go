@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/danhale-git/runrdp/internal/config"
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+	"github.com/danhale-git/runrdp/internal/picker"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -14,52 +20,54 @@ import (
 func findCommand() *cobra.Command {
 	// findCmd represents the find command
 	command := &cobra.Command{
-		Use:   "find",
-		Short: "TBD",
-		Long:  `TBD`,
+		Use:   "find [pattern]",
+		Short: "Search for a host and connect to it",
+		Long: `Open an interactive picker which ranks hosts by fuzzy match as you type. The selected host's type, global
+fields and the cred and tunnel it refers to are shown in a preview pane. Use the arrow keys to move, Enter to connect
+and Esc to cancel.
+
+If the terminal is not interactive the best matches for the pattern are listed and a number is read from stdin.
+
+Use --tag to only search hosts which have the given tags.`,
 		Args: func(cmd *cobra.Command, args []string) error {
-			return cobra.RangeArgs(1, 1)(cmd, args)
+			return cobra.RangeArgs(0, 1)(cmd, args)
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			sortedHostKeys := configuration.HostsSortedByPattern(args[0])
-			if len(sortedHostKeys) == 0 {
-				fmt.Println("No host configurations have been loaded.")
-				return
+			pattern := ""
+			if len(args) > 0 {
+				pattern = args[0]
 			}
 
-			c := minInt(viper.GetInt("count"), len(sortedHostKeys))
-			for i := 0; i < c; i++ {
-				if sortedHostKeys[i] == "" {
-					break
-				}
-				fmt.Printf("%d. %s\n", i+1, sortedHostKeys[i])
-			}
-
-			fmt.Print("\nEnter number to connect: ")
-			reader := bufio.NewReader(os.Stdin)
-			text, err := reader.ReadString('\n')
-
-			if err != nil {
-				panic(err)
+			if len(configuration.Hosts) == 0 {
+				fmt.Println("No host configurations have been loaded.")
+				return
 			}
 
-			selected, err := strconv.Atoi(strings.Trim(text, "\r\n"))
+			tags := viper.GetStringSlice("tag")
 
-			if err != nil {
-				fmt.Printf("Entered value was not a whole number: %s\n", err)
+			hostKeys := configuration.HostsWithTags(tags)
+			if len(hostKeys) == 0 {
+				fmt.Printf("No hosts have the tags: %s\n", strings.Join(tags, ", "))
 				return
 			}
 
-			if selected < 0 || selected > c {
-				fmt.Printf("Host number %d is not listed. Use -c <value> to list more hosts.\n", selected)
+			host, err := picker.Run(hostKeys, pattern, hostPreview)
+
+			switch {
+			case err == nil:
+				connectToHost(host)
+			case errors.Is(err, picker.ErrCancelled):
 				return
+			case errors.Is(err, picker.ErrNotTerminal):
+				findFromList(pattern, tags)
+			default:
+				log.Fatal(err)
 			}
-
-			connectToHost(sortedHostKeys[selected-1])
 		},
 	}
 
-	command.Flags().IntP("count", "c", 6, "The number of results to display.")
+	command.Flags().IntP("count", "c", 6, "The number of results to display when the terminal is not interactive.")
+	command.Flags().StringSliceP("tag", "t", []string{}, "Only search hosts with this tag. May be repeated.")
 
 	err := viper.BindPFlags(command.Flags())
 	if err != nil {
@@ -69,6 +77,81 @@ func findCommand() *cobra.Command {
 	return command
 }
 
+// findFromList prints the hosts with the given tags which best match the pattern and connects to the one whose number
+// is entered.
+func findFromList(pattern string, tags []string) {
+	sortedHostKeys := make([]string, 0)
+	for _, h := range configuration.HostsSortedByPattern(pattern) {
+		if configuration.HostHasTags(h, tags) {
+			sortedHostKeys = append(sortedHostKeys, h)
+		}
+	}
+
+	if len(sortedHostKeys) == 0 {
+		fmt.Printf("No hosts match '%s'.\n", pattern)
+		return
+	}
+
+	c := minInt(viper.GetInt("count"), len(sortedHostKeys))
+	for i := 0; i < c; i++ {
+		if sortedHostKeys[i] == "" {
+			break
+		}
+		fmt.Printf("%d. %s\n", i+1, sortedHostKeys[i])
+	}
+
+	fmt.Print("\nEnter number to connect: ")
+	reader := bufio.NewReader(os.Stdin)
+	text, err := reader.ReadString('\n')
+
+	if err != nil {
+		panic(err)
+	}
+
+	selected, err := strconv.Atoi(strings.Trim(text, "\r\n"))
+
+	if err != nil {
+		fmt.Printf("Entered value was not a whole number: %s\n", err)
+		return
+	}
+
+	if selected < 1 || selected > c {
+		fmt.Printf("Host number %d is not listed. Use -c <value> to list more hosts.\n", selected)
+		return
+	}
+
+	connectToHost(sortedHostKeys[selected-1])
+}
+
+// hostPreview returns the config of a host followed by the cred and tunnel entries it refers to.
+func hostPreview(host string) []string {
+	lines := entryPreview(fmt.Sprintf("host.%s.%s", configuration.HostType(host), host))
+
+	globals := configuration.HostGlobals[host]
+
+	if name := globals[hosts.GlobalCred.String()]; name != "" {
+		lines = append(lines, "")
+		lines = append(lines, entryPreview(fmt.Sprintf("cred.%s.%s", configuration.CredType(name), name))...)
+	}
+
+	if name := globals[hosts.GlobalTunnel.String()]; name != "" {
+		lines = append(lines, "")
+		lines = append(lines, entryPreview(fmt.Sprintf("tunnel.%s", name))...)
+	}
+
+	return lines
+}
+
+// entryPreview returns the parsed fields of an entry as TOML lines.
+func entryPreview(key string) []string {
+	fields, ok := configuration.EntryFields(key)
+	if !ok {
+		return []string{fmt.Sprintf("%s (not found)", key)}
+	}
+
+	return strings.Split(strings.TrimRight(config.Entry{Key: key, Fields: fields}.TOML(), "\n"), "\n")
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
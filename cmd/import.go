@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config"
+	"github.com/danhale-git/runrdp/internal/rdp"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func importCommand() *cobra.Command {
+	// importCmd represents the import command
+	command := &cobra.Command{
+		Use:   "import <file or directory>...",
+		Short: "Import .rdp files as host configurations",
+		Long: `Import .rdp files, or all .rdp files in a directory, as basic hosts. Display settings and RDP file properties
+are imported as settings entries. Hosts with identical settings share one settings entry. Hosts are named after their
+file name. Entries are appended to a file in the config root.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths, err := rdpFilePaths(args)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			files := make(map[string]*rdp.RDP)
+			for _, p := range paths {
+				name := config.EntryName(strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)))
+				if err := config.ValidateEntryName(name); err != nil {
+					log.Fatalf("%s: can't name a host after this file, rename it using letters or digits: %s", p,
+						err)
+				}
+
+				if _, ok := files[name]; ok {
+					log.Fatalf("%s: %s", p, &config.DuplicateConfigNameError{Name: name})
+				}
+
+				files[name], err = readRDPFile(p)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			entries, err := config.RDPFileEntries(files)
+			if err != nil {
+				log.Fatalf("importing .rdp files: %s", err)
+			}
+
+			if err := configuration.CheckNewEntries(entries); err != nil {
+				log.Fatalf("importing .rdp files: %s", err)
+			}
+
+			body := config.EntriesTOML(entries)
+
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+				fmt.Print(body)
+				return
+			}
+
+			name, _ := cmd.Flags().GetString("file")
+			path := filepath.Join(viper.GetString("config-root"), name)
+
+			if err := appendToFile(path, body); err != nil {
+				log.Fatalf("writing imported config: %s", err)
+			}
+
+			fmt.Printf("imported %d .rdp files to %s\n", len(files), path)
+		},
+	}
+
+	command.Flags().String("file", "imported.toml", "Name of the file in the config root to append entries to")
+	command.Flags().Bool("dry-run", false, "Print the imported entries without writing them")
+
+	return command
+}
+
+// rdpFilePaths returns the given paths, replacing directories with the .rdp files they contain.
+func rdpFilePaths(args []string) ([]string, error) {
+	paths := make([]string, 0)
+
+	for _, a := range args {
+		info, err := os.Stat(a)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			paths = append(paths, a)
+			continue
+		}
+
+		infos, err := ioutil.ReadDir(a)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range infos {
+			if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".rdp") {
+				paths = append(paths, filepath.Join(a, f.Name()))
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+func readRDPFile(path string) (*rdp.RDP, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println("error closing file:", err)
+		}
+	}()
+
+	r, unsupported, err := rdp.ParseFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if r.Address == "" {
+		return nil, fmt.Errorf("%s: no 'full address' setting was found", path)
+	}
+
+	if len(unsupported) > 0 {
+		fmt.Printf("%s: ignoring unsupported settings: %s\n", path, strings.Join(unsupported, ", "))
+	}
+
+	return r, nil
+}
+
+// appendToFile appends body to the file at path, separated from any existing content by a blank line. The file is
+// created if it doesn't exist.
+func appendToFile(path, body string) error {
+	info, err := os.Stat(path)
+	if err == nil && info.Size() > 0 {
+		body = "\n" + body
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(body); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func listCommand() *cobra.Command {
+	// listCmd represents the list command
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "List hosts with their type and tags",
+		Long: `List the hosts in the config in alphabetical order with their type and tags.
+
+Use --tag to only list hosts which have the given tags. If --tag is given more than once, hosts must have all of the
+tags.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			// The flag is read directly because the find command binds a flag with the same name to viper.
+			tags, err := cmd.Flags().GetStringSlice("tag")
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, h := range configuration.HostsWithTags(tags) {
+				fmt.Printf("%-20s %-10s %s\n", h, configuration.HostType(h),
+					strings.Join(configuration.HostTags[h], ", "))
+			}
+		},
+	}
+
+	command.Flags().StringSliceP("tag", "t", []string{}, "Only list hosts with this tag. May be repeated.")
+
+	return command
+}
@@ -2,16 +2,18 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/danhale-git/runrdp/internal/config/creds"
 	"github.com/danhale-git/runrdp/internal/config/hosts"
 
 	"github.com/danhale-git/runrdp/internal/config"
@@ -27,7 +29,11 @@ import (
 )
 
 var configuration *config.Configuration
+var configFiles map[string]*viper.Viper // Data from individual config files by file path
 var debug bool
+var environment string // Name of the active environment, or an empty string if there isn't one
+
+var askedPasswords = make(map[string]string) // Passwords entered for --ask-password by username
 
 // Execute begins execution of the CLI program
 func Execute() {
@@ -38,8 +44,19 @@ func Execute() {
 		panic(err)
 	}
 
+	if err = viper.BindEnv("env", envVariable); err != nil {
+		panic(err)
+	}
+
 	root.AddCommand(findCommand())
+	root.AddCommand(listCommand())
 	root.AddCommand(versionCommand())
+	root.AddCommand(importCommand())
+	root.AddCommand(exportCommand())
+	root.AddCommand(configureCommand())
+	root.AddCommand(validateCommand())
+	root.AddCommand(envCommand())
+	root.AddCommand(credCommand())
 
 	if err = root.Execute(); err != nil {
 		log.Fatal(err)
@@ -96,20 +113,39 @@ func rootCommand() *cobra.Command {
 	command.PersistentFlags().StringP("password", "p", "",
 		"Password to authenticate with",
 	)
+	command.PersistentFlags().Bool("ask-password", false,
+		"Prompt for the password to authenticate with instead of using the configured password, unless --password is given",
+	)
+
+	command.PersistentFlags().String("client", "",
+		fmt.Sprintf("RDP client used to start the session, one of: %s", strings.Join(rdp.LauncherNames(), ", ")),
+	)
 
 	// RunRDP config
 	command.PersistentFlags().Bool("debug", false,
 		"Print debug information",
 	)
 
+	command.PersistentFlags().Bool("no-strict", false,
+		"Warn about references to config entries which don't exist instead of exiting",
+	)
+
 	command.PersistentFlags().String("tempfile-path", filepath.Join(configRoot, "connection.rdp"),
-		"The directory in which a temporary .rdp file will be saved and run. Default is ~/.runrdp/",
+		"Path of the temporary .rdp file. Each session writes its own file in this directory, named after it",
 	)
 
 	command.PersistentFlags().String("config-root", configRoot,
 		"directory containing config files",
 	)
 
+	command.PersistentFlags().String("env", "",
+		fmt.Sprintf("Environment to use, overriding %s and the environment set with 'runrdp env use'", envVariable),
+	)
+
+	command.PersistentFlags().Bool("recursive", false,
+		"Also read config files in sub-directories of the config root",
+	)
+
 	command.PersistentFlags().String("ssh-directory", path.Join(home, ".ssh"),
 		"Directory containing SSH keys.",
 	)
@@ -118,21 +154,48 @@ func rootCommand() *cobra.Command {
 }
 
 func PersistentPreRun(_ *cobra.Command, _ []string) {
+	loadConfiguration(!viper.GetBool("no-strict"))
+}
+
+// loadConfiguration reads all config files, applies the active environment and parses them. If strict is false,
+// references between entries which can't be resolved are printed as warnings instead of stopping the program.
+func loadConfiguration(strict bool) {
 	debug = viper.GetBool("debug")
 
-	vipers, err := readAllConfigs(viper.GetString("config-root"), ".toml")
+	var err error
+	configFiles, err = readAllConfigs(viper.GetString("config-root"), ".toml")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	configuration, err = config.New(vipers)
+	environment = activeEnvironment()
+	if environment != "" {
+		configFiles, err = loadEnvironment(configFiles, environment)
+		if err != nil {
+			log.Fatalf("loading environment %s: %s", environment, err)
+		}
+	}
+
+	configuration, err = config.New(configFiles, strict)
 	if err != nil {
 		log.Fatalf("parsing configs: %s", err)
 	}
+
+	if !strict {
+		if err := configuration.CheckReferences(); err != nil {
+			fmt.Println("WARNING:", err)
+		}
+	}
+
+	if debug {
+		for _, k := range configuration.UnusedEntries() {
+			fmt.Println("unused config entry:", k)
+		}
+	}
 }
 
-// Run attempts to locate the given argument in the hosts config. If it is not a config entry the argument is validated
-// as a socket and a connection is attempted if validation passes.
+// Run attempts to locate the given argument in the hosts config. If it is not a host but is a group, every host in the
+// group is connected to after confirmation.
 func Run(_ *cobra.Command, args []string) {
 	// Config keys are always parsed to lower case.
 	arg := strings.ToLower(args[0])
@@ -140,26 +203,60 @@ func Run(_ *cobra.Command, args []string) {
 	if configuration.HostExists(arg) {
 		connectToHost(arg)
 		return
+	} else if configuration.GroupExists(arg) {
+		connectToGroup(arg)
+		return
 	} else {
 		fmt.Printf("host %s does not exist in config\n", arg)
 	}
 }
 
+// connectToGroup lists the hosts in the group and connects to each of them if the user confirms.
+func connectToGroup(group string) {
+	groupHosts := configuration.GroupHosts(group)
+	if len(groupHosts) == 0 {
+		fmt.Printf("group %s does not contain any hosts\n", group)
+		return
+	}
+
+	fmt.Printf("group %s contains %d hosts:\n", group, len(groupHosts))
+	for _, h := range groupHosts {
+		fmt.Printf("  %s\n", h)
+	}
+
+	fmt.Print("Connect to all of them? (y/n): ")
+	if !interactiveYesNo() {
+		return
+	}
+
+	started, failed := startSessions(groupHosts)
+
+	for _, h := range groupHosts {
+		if err, ok := failed[h]; ok {
+			fmt.Printf("%s: %s\n", h, err)
+		}
+	}
+
+	closeTunnels(started)
+
+	if len(failed) > 0 {
+		log.Fatalf("failed to connect to %d of %d hosts in group %s", len(failed), len(groupHosts), group)
+	}
+}
+
 func readAllConfigs(directory, extension string) (map[string]*viper.Viper, error) {
-	infos, err := ioutil.ReadDir(directory)
+	paths, includedBy, err := config.FindConfigFiles(directory, extension, viper.GetBool("recursive"))
 	if err != nil {
 		return nil, err
 	}
 
 	files := make([]*os.File, 0)
-	for _, f := range infos {
-		if filepath.Ext(f.Name()) == extension {
-			f, err := os.Open(filepath.Join(directory, f.Name()))
-			if err != nil {
-				return nil, err
-			}
-			files = append(files, f)
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
 		}
+		files = append(files, f)
 	}
 
 	if debug {
@@ -168,7 +265,12 @@ func readAllConfigs(directory, extension string) (map[string]*viper.Viper, error
 	configs := make(map[string]io.Reader)
 	for _, f := range files {
 		if debug {
-			fmt.Println("reading config:", filepath.Base(f.Name()))
+			if parent, ok := includedBy[f.Name()]; ok {
+				fmt.Printf("reading config: %s (included by %s)\n", relativeConfigPath(f.Name()),
+					relativeConfigPath(parent))
+			} else {
+				fmt.Println("reading config:", relativeConfigPath(f.Name()))
+			}
 		}
 		configs[f.Name()] = f
 	}
@@ -184,74 +286,223 @@ func readAllConfigs(directory, extension string) (map[string]*viper.Viper, error
 		}
 	}
 
+	if debug {
+		for _, p := range paths {
+			for _, key := range config.EntryKeys(vipers[p]) {
+				fmt.Printf("loaded %s from %s\n", key, relativeConfigPath(p))
+			}
+		}
+	}
+
 	return vipers, nil
 }
 
 func connectToHost(host string) {
-	address, port := getSocket(host)
+	s, err := newSession(host)
+	if err != nil {
+		log.Fatalf("%s: %s", host, err)
+	}
+
+	if err := s.start(); err != nil {
+		if s.tunnel != nil {
+			s.tunnel.Stop()
+		}
+		log.Fatal(err)
+	}
 
-	username, password := getCredentials(host)
+	closeTunnels([]*session{s})
+}
+
+// session is an RDP session to a host which is ready to be started.
+type session struct {
+	host   string
+	params rdp.RDP
+	client string
+	tunnel *sshtun.SSHTun // The tunnel the session connects through, nil if the host doesn't have one
+}
+
+// newSession gets the socket, credentials and settings of the given host and opens its SSH tunnel if it has one.
+// Credentials may be prompted for, so sessions must be created one at a time.
+func newSession(host string) (*session, error) {
+	address, port, err := getSocket(host)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password, err := getCredentials(host)
+	if err != nil {
+		return nil, err
+	}
 
 	if port == "" {
 		port = rdp.DefaultPort
 	}
 
 	var tunnel *sshtun.SSHTun
-	t, ok := configuration.Tunnels[configuration.HostGlobals[host][hosts.GlobalTunnel.String()]]
+	tunnelName := configuration.HostGlobals[host][hosts.GlobalTunnel.String()]
+	t, ok := configuration.Tunnels[tunnelName]
+	if !ok && tunnelName != "" {
+		fmt.Printf("WARNING: %s: tunnel '%s' does not exist, connecting without a tunnel\n", host, tunnelName)
+	}
 	if ok {
-		var err error
 		tunnel, err = sshTunnel(&t, address, port)
 		if err != nil {
-			log.Fatalf("opening ssh tunnel: %s", err)
+			return nil, fmt.Errorf("opening ssh tunnel: %w", err)
 		}
 
 		address = "localhost"
 		port = t.LocalPort
-
-		defer tunnel.Stop()
 	}
 
 	settings := getSettings(host)
 
-	params := rdp.RDP{
-		Username: username, Password: password,
-		Address: address, Port: port,
-		Width: settings.Width, Height: settings.Height,
-		Fullscreen: settings.Fullscreen, Public: settings.Public, Span: settings.Span,
-	}
+	return &session{
+		host:   host,
+		params: sessionParams(&settings, username, password, address, port),
+		client: settings.Client,
+		tunnel: tunnel,
+	}, nil
+}
 
-	fmt.Printf("connecting to %s: %s:%s\n", host, address, port)
+// start connects to the remote desktop.
+func (s *session) start() error {
+	if environment != "" {
+		fmt.Printf("connecting to %s in %s: %s:%s\n", s.host, environment, s.params.Address, s.params.Port)
+	} else {
+		fmt.Printf("connecting to %s: %s:%s\n", s.host, s.params.Address, s.params.Port)
+	}
 
 	if debug {
-		b, err := json.MarshalIndent(params, "", "  ")
+		b, err := json.MarshalIndent(s.params, "", "  ")
 		if err != nil {
 			log.Printf("error marshaling parameters for --debug: %s", err)
 		} else {
-			fmt.Println(strings.Replace(string(b), password, "REMOVED", 1))
+			fmt.Println(strings.Replace(string(b), s.params.Password, "REMOVED", 1))
 		}
 	}
 
-	// Connect to the remote desktop.
-	if err := rdp.Connect(&params, debug); err != nil {
-		if tunnel != nil {
-			tunnel.Stop()
+	return rdp.Connect(&s.params, s.client, debug)
+}
+
+// startSessions creates a session for each of the given hosts, then starts them all at once, except those which must
+// be started one at a time (see rdp.Serial). It returns once every session has started, with the sessions which
+// started successfully and the error of each host which failed. The tunnels of hosts which failed are closed.
+func startSessions(hostNames []string) ([]*session, map[string]error) {
+	failed := make(map[string]error)
+	sessions := make([]*session, 0, len(hostNames))
+
+	for _, h := range hostNames {
+		s, err := newSession(h)
+		if err != nil {
+			failed[h] = err
+			continue
 		}
-		log.Fatal(err)
+
+		sessions = append(sessions, s)
 	}
 
-	// Close SSH connection when program exits. Wait for user to confirm before exiting.
-	if tunnel != nil {
-		fmt.Println("Press Enter to close SSH tunnel")
-		if _, err := fmt.Scanln(); err != nil {
-			log.Fatal(err)
+	errs := make([]error, len(sessions))
+	serial := make([]int, 0)
+
+	var wg sync.WaitGroup
+	for i, s := range sessions {
+		if rdp.Serial(s.client) {
+			serial = append(serial, i)
+			continue
 		}
+
+		wg.Add(1)
+
+		go func(i int, s *session) {
+			defer wg.Done()
+			errs[i] = s.start()
+		}(i, s)
 	}
+
+	// Sessions which can't be started at the same time are started one after another, alongside the others. The
+	// password of each is copied to the clipboard, so wait for the user before replacing it.
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for n, i := range serial {
+			if n > 0 && sessions[i].params.Password != "" {
+				fmt.Printf("Press Enter to connect to %s, replacing the password in the clipboard\n", sessions[i].host)
+				_, _ = fmt.Scanln()
+			}
+
+			errs[i] = sessions[i].start()
+		}
+	}()
+
+	wg.Wait()
+
+	started := make([]*session, 0, len(sessions))
+
+	for i, s := range sessions {
+		if errs[i] == nil {
+			started = append(started, s)
+			continue
+		}
+
+		failed[s.host] = errs[i]
+
+		if s.tunnel != nil {
+			s.tunnel.Stop()
+		}
+	}
+
+	return started, failed
 }
 
-func getSocket(host string) (string, string) {
+// closeTunnels waits for the user to confirm, then closes the SSH tunnels of the given sessions. It returns
+// immediately if none of the sessions have a tunnel.
+func closeTunnels(sessions []*session) {
+	tunnels := make([]*sshtun.SSHTun, 0)
+	for _, s := range sessions {
+		if s.tunnel != nil {
+			tunnels = append(tunnels, s.tunnel)
+		}
+	}
+
+	if len(tunnels) == 0 {
+		return
+	}
+
+	// Close SSH connections when program exits. Wait for user to confirm before exiting.
+	if len(tunnels) == 1 {
+		fmt.Println("Press Enter to close SSH tunnel")
+	} else {
+		fmt.Printf("Press Enter to close %d SSH tunnels\n", len(tunnels))
+	}
+
+	_, err := fmt.Scanln()
+
+	for _, t := range tunnels {
+		t.Stop()
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// sessionParams returns the parameters for an RDP session with the given settings.
+func sessionParams(settings *config.Settings, username, password, address, port string) rdp.RDP {
+	return rdp.RDP{
+		Username: username, Password: password,
+		Address: address, Port: port,
+		Width: settings.Width, Height: settings.Height,
+		Fullscreen: settings.Fullscreen, Public: settings.Public, Span: settings.Span,
+		Properties: settings.Properties,
+	}
+}
+
+func getSocket(host string) (string, string, error) {
 	address, port, err := configuration.HostSocket(host, false)
 	if err != nil {
-		log.Fatalf("error getting host socket: %s", err)
+		return "", "", fmt.Errorf("getting host socket: %w", err)
 	}
 
 	if viper.GetString("address") != "" {
@@ -262,11 +513,14 @@ func getSocket(host string) (string, string) {
 		port = viper.GetString("port")
 	}
 
-	return address, port
+	return address, port, nil
 }
 
-func getCredentials(host string) (string, string) {
+func getCredentials(host string) (string, string, error) {
 	username, password, err := configuration.HostCredentials(host)
+	if errors.Is(err, creds.ErrNotTerminal) || errors.Is(err, creds.ErrPasswordMismatch) {
+		return "", "", err
+	}
 	if err != nil {
 		fmt.Printf("error getting host credentials: %s\n", err)
 	}
@@ -275,11 +529,35 @@ func getCredentials(host string) (string, string) {
 		username = viper.GetString("username")
 	}
 
+	// --password takes precedence, so there is no need to prompt for a password which wouldn't be used
 	if viper.GetString("password") != "" {
 		password = viper.GetString("password")
+	} else if viper.GetBool("ask-password") {
+		password = askPassword(username)
+	}
+
+	return username, password, nil
+}
+
+// askPassword prompts for the password of the given user. The password is only prompted for once for each user.
+func askPassword(username string) string {
+	if password, ok := askedPasswords[username]; ok {
+		return password
+	}
+
+	message := "Password: "
+	if username != "" {
+		message = fmt.Sprintf("Password for %s: ", username)
 	}
 
-	return username, password
+	password, err := creds.PromptPassword(message, false)
+	if err != nil {
+		log.Fatalf("--ask-password: %s", err)
+	}
+
+	askedPasswords[username] = password
+
+	return password
 }
 
 func getSettings(host string) config.Settings {
@@ -289,6 +567,9 @@ func getSettings(host string) config.Settings {
 			host, config.DefaultSettingsName, config.DefaultSettingsName)
 	}
 	settings, ok := configuration.Settings[name]
+	if !ok && name != "" {
+		fmt.Printf("WARNING: %s: settings '%s' do not exist, using default settings\n", host, name)
+	}
 
 	if !ok {
 		dfault, ok := configuration.Settings[config.DefaultSettingsName]
@@ -299,26 +580,33 @@ func getSettings(host string) config.Settings {
 		}
 	}
 
+	overrideSettings(&settings)
+
+	// Always disable public because there's no need to store configuration with runrdp
+	settings.Public = false
+
+	return settings
+}
+
+// overrideSettings applies the command line flags to settings from the config. The boolean flags can only enable
+// fullscreen and span, so settings which enable them in the config are not disabled when the flags are not given.
+func overrideSettings(settings *config.Settings) {
 	if viper.GetInt("height") != 0 {
 		settings.Height = viper.GetInt("height")
 	}
 	if viper.GetInt("width") != 0 {
 		settings.Width = viper.GetInt("width")
 	}
-	if !viper.GetBool("fullscreen") {
-		settings.Fullscreen = viper.GetBool("fullscreen")
+	if viper.GetBool("fullscreen") {
+		settings.Fullscreen = true
 	}
-	if !viper.GetBool("public") {
-		settings.Public = viper.GetBool("public")
-	}
-	if !viper.GetBool("span") {
-		settings.Span = viper.GetBool("span")
+	if viper.GetBool("span") {
+		settings.Span = true
 	}
 
-	// Always disable public because there's no need to store configuration with runrdp
-	settings.Public = false
-
-	return settings
+	if viper.GetString("client") != "" {
+		settings.Client = viper.GetString("client")
+	}
 }
 
 // sshTunnel open an SSH tunnel (port forwarding) equivalent to the command below:
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestStartSessions(t *testing.T) {
+	setConfiguration(t, `
+[host.basic.web]
+    address = "10.0.0.1"
+
+[host.basic.noaddress]
+    username = "admin"
+
+[host.basic.sql]
+    address = "10.0.0.2"`)
+
+	viper.Set("client", "print")
+	t.Cleanup(func() { viper.Set("client", "") })
+
+	started, failed := startSessions([]string{"web", "noaddress", "sql"})
+
+	hostNames := make([]string, len(started))
+	for i, s := range started {
+		hostNames[i] = s.host
+	}
+
+	if strings.Join(hostNames, ",") != "web,sql" {
+		t.Errorf("unexpected sessions started: want web,sql: got %s", strings.Join(hostNames, ","))
+	}
+
+	if len(failed) != 1 || failed["noaddress"] == nil {
+		t.Errorf("unexpected failed hosts: want noaddress: got %v", failed)
+	}
+}
+
+func TestGetCredentials_PasswordFlag(t *testing.T) {
+	setConfiguration(t, `
+[host.basic.web]
+    address = "10.0.0.1"
+    username = "admin"`)
+
+	// --ask-password would fail to prompt because the test isn't run in a terminal
+	viper.Set("password", "flagpassword")
+	viper.Set("ask-password", true)
+	t.Cleanup(func() {
+		viper.Set("password", "")
+		viper.Set("ask-password", false)
+	})
+
+	username, password, err := getCredentials("web")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if username != "admin" || password != "flagpassword" {
+		t.Errorf("unexpected credentials: want admin/flagpassword: got %s/%s", username, password)
+	}
+}
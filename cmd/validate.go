@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/danhale-git/runrdp/internal/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func validateCommand() *cobra.Command {
+	// validateCmd represents the validate command
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check all config files for problems",
+		Long: fmt.Sprintf(`Check every config file in the config root, and the files they include, and report all problems found,
+with the file, line and column of each. The files of each environment in %s/<name> are checked together with the
+other config files. References to entries which don't exist and hosts which refer to each other through proxy or
+tunnel fields are also reported, with and without each environment applied. Exits with a non-zero status if there are
+any problems, so it can be used in a pre-commit hook.`, config.EnvDirectory),
+		Args: cobra.NoArgs,
+		// Override the root command so the config is not parsed, which would stop at the first problem.
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			debug = viper.GetBool("debug")
+		},
+		Run: func(_ *cobra.Command, _ []string) {
+			root := viper.GetString("config-root")
+
+			files := readConfigBytes(root)
+			problems := config.Validate(files)
+			count := len(files)
+
+			for _, name := range envDirectoryNames() {
+				dir, _ := envDirectory(name)
+				envFiles := readConfigBytes(dir)
+				count += len(envFiles)
+
+				// Problems in the other files have already been found
+				for _, p := range config.ValidateEnvironment(files, envFiles) {
+					if _, ok := envFiles[p.File]; ok {
+						problems = append(problems, p)
+					}
+				}
+			}
+
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+
+			if len(problems) > 0 {
+				fmt.Printf("found %s in %s\n", plural(len(problems), "problem"), plural(count, "config file"))
+				os.Exit(1)
+			}
+
+			// References can only be checked once all files have been parsed successfully
+			var err error
+			configFiles, err = readAllConfigs(root, ".toml")
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			c, err := config.New(configFiles, false)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, k := range c.UnusedEntries() {
+				fmt.Printf("WARNING: %s is not used by any host\n", k)
+			}
+
+			valid := checkReferences("", c)
+
+			for _, name := range environmentNames() {
+				base := make(map[string]*viper.Viper, len(configFiles))
+				for k, v := range configFiles {
+					base[k] = v
+				}
+
+				envFiles, err := loadEnvironment(base, name)
+				if err != nil {
+					fmt.Printf("environment %s: %s\n", name, err)
+					valid = false
+					continue
+				}
+
+				c, err := config.New(envFiles, false)
+				if err != nil {
+					fmt.Printf("environment %s: %s\n", name, err)
+					valid = false
+					continue
+				}
+
+				valid = checkReferences(name, c) && valid
+			}
+
+			if !valid {
+				os.Exit(1)
+			}
+
+			if count == 1 {
+				fmt.Println("1 config file is valid")
+			} else {
+				fmt.Printf("%d config files are valid\n", count)
+			}
+		},
+	}
+}
+
+// checkReferences prints the problems with references between the entries of c and returns false if there are any.
+// env is the name of the environment applied to c, if any.
+func checkReferences(env string, c *config.Configuration) bool {
+	err := c.CheckReferences()
+	if err == nil {
+		return true
+	}
+
+	if env != "" {
+		fmt.Printf("environment %s: ", env)
+	}
+	fmt.Println(err)
+
+	return false
+}
+
+// readConfigBytes returns the contents of the config files in directory, and the files they include, by path.
+func readConfigBytes(directory string) map[string][]byte {
+	paths, _, err := config.FindConfigFiles(directory, ".toml", viper.GetBool("recursive"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files := make(map[string][]byte)
+	for _, p := range paths {
+		if debug {
+			fmt.Println("validating config:", p)
+		}
+
+		files[p], err = ioutil.ReadFile(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return files
+}
+
+// plural returns n followed by word, with an s added unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+
+	return fmt.Sprintf("%d %ss", n, word)
+}
@@ -0,0 +1,11 @@
+package cmd
+
+import "testing"
+
+func TestPlural(t *testing.T) {
+	for n, want := range map[int]string{0: "0 problems", 1: "1 problem", 2: "2 problems"} {
+		if got := plural(n, "problem"); got != want {
+			t.Errorf("unexpected result for %d: want '%s': got '%s'", n, want, got)
+		}
+	}
+}
@@ -3,20 +3,25 @@ module github.com/danhale-git/runrdp
 go 1.16
 
 require (
+	filippo.io/age v1.0.0
 	github.com/atotto/clipboard v0.1.2
 	github.com/aws/aws-sdk-go v1.38.35
 	github.com/danhale-git/tss-sdk-go v1.1.0
+	github.com/godbus/dbus/v5 v5.0.4
 	github.com/kr/text v0.2.0 // indirect
 	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/pelletier/go-toml v1.9.3
 	github.com/rgzr/sshtun v0.0.2
 	github.com/sahilm/fuzzy v0.1.0
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 	github.com/smartystreets/assertions v1.0.0 // indirect
 	github.com/spf13/cobra v1.2.1
 	github.com/spf13/viper v1.8.1
-	golang.org/x/crypto v0.0.0-20210506145944-38f3c27a63bf // indirect
+	github.com/zalando/go-keyring v0.1.1
+	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
 	golang.org/x/term v0.0.0-20210615171337-6886f2dfbf5b
 	golang.org/x/text v0.3.6 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,12 +1,14 @@
 package config
 
 import (
-	"bytes"
 	"fmt"
 	"io"
+	"reflect"
+	"sort"
 
 	"github.com/sahilm/fuzzy"
 
+	"github.com/danhale-git/runrdp/internal/config/creds"
 	"github.com/danhale-git/runrdp/internal/config/hosts"
 
 	"github.com/spf13/viper"
@@ -17,10 +19,15 @@ type Configuration struct {
 	//Data        map[string]*viper.Viper    // Data from individual config files
 	Hosts       map[string]Host              // All configured hosts
 	HostGlobals map[string]map[string]string // Global Host fields by [host key][field name]. All keys exist for all hosts, undefined values are empty strings
+	HostTags    map[string][]string          // Tags of each host by host key, in lower case
+	Templates   map[string]bool              // Template entries by host type and name, for example basic.sql
+	Extends     map[string]string            // Name of the entry extended by each host and template, by entry key
+	Vars        map[string]string            // Variables defined in [vars] tables, used in ${var:name} references
 
 	Creds    map[string]Cred     `mapstructure:"cred"`
 	Tunnels  map[string]Tunnel   `mapstructure:"tunnel"`
 	Settings map[string]Settings `mapstructure:"setting"`
+	Groups   map[string]Group    `mapstructure:"group"`
 }
 
 // Host can return a hostname or IP address and/or a port.
@@ -35,53 +42,70 @@ type Cred interface {
 	Validate() error
 }
 
-// ReadConfigs reads a map of io.Reader into a matching map of viper.Viper. All config files are also concatenated with
-// newline delimiters and read into the global viper instance.
+// ReadConfigs reads a map of io.Reader into a matching map of viper.Viper. The settings from all config files are also
+// merged into the global viper instance. A *DuplicateConfigNameError is returned if an entry is defined in more than
+// one config.
 func ReadConfigs(readers map[string]io.Reader) (map[string]*viper.Viper, error) {
-	concatConfig := make([]byte, 0)
+	names := make([]string, 0, len(readers))
+	for k := range readers {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
 
 	vipers := make(map[string]*viper.Viper)
-	for k, r := range readers {
-		var buf bytes.Buffer
-		tee := io.TeeReader(r, &buf)
+	definedIn := make(map[string]string) // Config name by entry key
 
-		// Read config once for individual viper instance
+	for _, k := range names {
 		v := viper.New()
 		v.SetConfigType("toml")
-		if err := v.ReadConfig(tee); err != nil {
-			return nil, fmt.Errorf("reading config: %w", err)
+		if err := v.ReadConfig(readers[k]); err != nil {
+			return nil, fmt.Errorf("reading config %s: %w", k, err)
 		}
 		vipers[k] = v
 
-		// Read config a second time for single concatenated viper instance
-		concatConfig = append(
-			concatConfig,
-			append(buf.Bytes(), []byte("\n\n")...)...,
-		)
-	}
+		for _, key := range EntryKeys(v) {
+			if first, ok := definedIn[key]; ok {
+				return nil, &DuplicateConfigNameError{Name: key, Files: []string{first, k}}
+			}
+
+			definedIn[key] = k
+		}
 
-	viper.SetConfigType("toml")
-	if err := viper.ReadConfig(bytes.NewReader(concatConfig)); err != nil {
-		return nil, fmt.Errorf("reading config: %w", err)
+		if err := viper.MergeConfigMap(v.AllSettings()); err != nil {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
 	}
 
 	return vipers, nil
 }
 
-// New takes a map of viper instances and parses them to a Configuration struct.
-func New(v map[string]*viper.Viper) (*Configuration, error) {
+// New takes a map of viper instances and parses them to a Configuration struct. If strict is true, a *ReferenceError is
+// returned if any references between entries can't be resolved (see CheckReferences).
+func New(v map[string]*viper.Viper, strict bool) (*Configuration, error) {
 	c := Configuration{}
 
 	c.Hosts = make(map[string]Host)
 	c.HostGlobals = make(map[string]map[string]string)
+	c.HostTags = make(map[string][]string)
+	c.Templates = make(map[string]bool)
+	c.Extends = make(map[string]string)
+	c.Vars = make(map[string]string)
 	c.Creds = make(map[string]Cred)
 	c.Tunnels = make(map[string]Tunnel)
 	c.Settings = make(map[string]Settings)
+	c.Groups = make(map[string]Group)
 
 	if err := parseConfiguration(v, &c); err != nil {
 		return nil, err
 	}
 
+	if strict {
+		if err := c.CheckReferences(); err != nil {
+			return nil, err
+		}
+	}
+
 	return &c, nil
 }
 
@@ -112,6 +136,39 @@ func (c *Configuration) HostKeys() []string {
 	return keys
 }
 
+// HostType returns the name of the type of the given host as used in config keys, for example 'awsec2'. An empty string
+// is returned if the host doesn't exist or its type is not in hosts.Map.
+func (c *Configuration) HostType(key string) string {
+	h, ok := c.Hosts[key]
+	if !ok {
+		return ""
+	}
+
+	return typeName(hosts.Map, h)
+}
+
+// CredType returns the name of the type of the given cred as used in config keys, for example 'awssm'. An empty string
+// is returned if the cred doesn't exist or its type is not in creds.Map.
+func (c *Configuration) CredType(key string) string {
+	cred, ok := c.Creds[key]
+	if !ok {
+		return ""
+	}
+
+	return typeName(creds.Map, cred)
+}
+
+// typeName returns the key in m of the function which creates values of the same type as v.
+func typeName(m map[string]func() interface{}, v interface{}) string {
+	for name, typeFunc := range m {
+		if reflect.TypeOf(typeFunc()) == reflect.TypeOf(v) {
+			return name
+		}
+	}
+
+	return ""
+}
+
 // HostExists returns true if the given host is in the configuration.
 func (c *Configuration) HostExists(key string) bool {
 	_, ok := c.Hosts[key]
@@ -123,7 +180,8 @@ func (c *Configuration) HostExists(key string) bool {
 // Either a username or a password may be provided through various means. The following sources are all tried, in
 // order from least to most preferred. The most preferred non-empty string is accepted for each field.
 //
-// - Values from calling creds.Cred.Retrieve() on the cred referred to by the global 'cred' field.
+// - Values from calling creds.Cred.Retrieve() on the cred referred to by the global 'cred' field, for example the
+// values of environment variables for a cred.env entry.
 //
 // - Values from calling creds.Cred.Retrieve() on the host if it implements creds.Cred.
 //
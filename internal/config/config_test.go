@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -56,10 +57,65 @@ func TestReadConfigs(t *testing.T) {
 
 }
 
+func TestReadConfigs_Duplicates(t *testing.T) {
+	_, err := ReadConfigs(readersFromStrings([]string{`
+[host.basic.web]
+    address = "10.0.0.1"`, `
+[host.basic.web]
+    address = "10.0.0.2"`,
+	}))
+
+	var dupErr *DuplicateConfigNameError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("unexpected error: expected DuplicateConfigNameError: got %v", err)
+	}
+
+	if dupErr.Name != "host.basic.web" || strings.Join(dupErr.Files, ",") != "config1,config2" {
+		t.Errorf("unexpected duplicate: want host.basic.web in config1,config2: got %s in %s", dupErr.Name,
+			strings.Join(dupErr.Files, ","))
+	}
+
+	// Entries with the same name and different keys are not duplicated in the raw config
+	if _, err := ReadConfigs(readersFromStrings([]string{`
+[host.basic.web]
+    address = "10.0.0.1"`, `
+[template.basic.web]
+    port = "3390"`,
+	})); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestRawEntries_Duplicates(t *testing.T) {
+	// Each file is read separately, as files are added when an environment is applied
+	vipers := make(map[string]*viper.Viper)
+	for i, s := range []string{`
+[tunnel.mytunnel]
+    host = "a"`, `
+[tunnel.mytunnel]
+    host = "b"`,
+	} {
+		name := fmt.Sprintf("config%d", i+1)
+		vipers[name] = vipersFromString(s)["config1"]
+	}
+
+	_, err := rawEntries(vipers, nil, "tunnel")
+
+	var dupErr *DuplicateConfigNameError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("unexpected error: expected DuplicateConfigNameError: got %v", err)
+	}
+
+	if dupErr.Name != "tunnel.mytunnel" || strings.Join(dupErr.Files, ",") != "config1,config2" {
+		t.Errorf("unexpected duplicate: want tunnel.mytunnel in config1,config2: got %s in %s", dupErr.Name,
+			strings.Join(dupErr.Files, ","))
+	}
+}
+
 func TestNew(t *testing.T) {
 	v := vipersFromString(mock.Config)
 
-	c, err := New(v)
+	c, err := New(v, false)
 	if err != nil {
 		t.Fatalf("unexpected error returned: %s", err)
 	}
@@ -101,7 +157,7 @@ func TestConfiguration_HostsSortedByPattern(t *testing.T) {
 	address = "1.2.3.4"
 [host.basic.zbc]
 	address = "1.2.3.4"`)
-	c, err := New(v)
+	c, err := New(v, true)
 	if err != nil {
 		t.Errorf("unexpected error creating config: %s", err)
 	}
@@ -130,7 +186,7 @@ func TestConfiguration_HostKeys(t *testing.T) {
 	address = "1.2.3.4"
 [host.basic.abc12345]
 	address = "1.2.3.4"`)
-	c, err := New(v)
+	c, err := New(v, true)
 	if err != nil {
 		t.Errorf("unexpected error creating config: %s", err)
 	}
@@ -149,7 +205,7 @@ func TestConfiguration_HostExists(t *testing.T) {
 	v := vipersFromString(`
 [host.basic.abc]
 	address = "1.2.3.4"`)
-	c, err := New(v)
+	c, err := New(v, true)
 	if err != nil {
 		t.Errorf("unexpected error creating config: %s", err)
 	}
@@ -164,7 +220,7 @@ func TestConfiguration_HostExists(t *testing.T) {
 }
 
 func TestConfiguration_HostCredentials(t *testing.T) {
-	c, err := New(map[string]*viper.Viper{})
+	c, err := New(map[string]*viper.Viper{}, false)
 	if err != nil {
 		t.Errorf("unexpected error creating config: %s", err)
 	}
@@ -237,7 +293,7 @@ func TestConfiguration_HostCredentials(t *testing.T) {
 }
 
 func TestConfiguration_HostSocket(t *testing.T) {
-	c, err := New(map[string]*viper.Viper{})
+	c, err := New(map[string]*viper.Viper{}, false)
 	if err != nil {
 		t.Errorf("unexpected error creating config: %s", err)
 	}
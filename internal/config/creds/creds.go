@@ -2,5 +2,20 @@ package creds
 
 // Map is the source of truth for a complete list of implemented host key names and struct functions.
 var Map = map[string]func() interface{}{
-	"awssm": SecretsManagerStruct,
+	"awssm":   SecretsManagerStruct,
+	"awsssm":  ParameterStoreStruct,
+	"env":     EnvStruct,
+	"prompt":  PromptStruct,
+	"vault":   VaultStruct,
+	"tss":     SecretServerStruct,
+	"keyring": KeyringStruct,
+	"pass":    PassStruct,
+	"keepass": KeePassStruct,
+	"exec":    ExecStruct,
+	"file":    FileStruct,
+}
+
+// Named is implemented by creds which need the name of their config entry, which is set after the cred is parsed.
+type Named interface {
+	SetName(name string)
 }
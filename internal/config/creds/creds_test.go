@@ -1,6 +1,17 @@
 package creds
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
 
 func TestSecretsManagerStruct(t *testing.T) {
 	var i interface{} = SecretsManagerStruct()
@@ -11,5 +22,273 @@ func TestSecretsManagerStruct(t *testing.T) {
 }
 
 func TestSecretsManager_Validate(t *testing.T) {
-	// Validate not yet implemented
+	for _, s := range []*SecretsManager{
+		{},
+		{UsernameID: "MyUsername", UsernameKey: "user"},
+		{UsernameID: "MyUsername", VersionStage: "AWSPREVIOUS"},
+		{PasswordID: "MyPassword", VersionID: "EXAMPLE1-90ab-cdef-fedc-ba987EXAMPLE"},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("no error returned for invalid config %+v", s)
+		}
+	}
+
+	if err := (&SecretsManager{SecretID: "MyCredentials", PasswordKey: "pass"}).Validate(); err != nil {
+		t.Errorf("unexpected error returned for valid config: %s", err)
+	}
+}
+
+type secretsManagerMock struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets  map[string]string
+	versions map[string]string // Version stage requested by secret ID
+}
+
+func (s *secretsManagerMock) GetSecretValue(
+	i *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	if s.versions != nil {
+		s.versions[*i.SecretId] = aws.StringValue(i.VersionStage)
+	}
+
+	secret := s.secrets[*i.SecretId]
+	return &secretsmanager.GetSecretValueOutput{SecretString: &secret}, nil
+}
+
+func TestSecretsManager_Retrieve(t *testing.T) {
+	svc := &secretsManagerMock{secrets: map[string]string{
+		"MyCredentials": `{"username":"smuser","password":"smpassword"}`,
+		"MyUsername":    "otheruser",
+	}}
+
+	s := &SecretsManager{SecretID: "MyCredentials", svc: svc}
+
+	username, password, err := s.Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if username != "smuser" || password != "smpassword" {
+		t.Errorf("unexpected credentials: want 'smuser', 'smpassword': got '%s', '%s'", username, password)
+	}
+
+	// A separate username secret takes precedence
+	s.UsernameID = "MyUsername"
+
+	username, _, err = s.Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if username != "otheruser" {
+		t.Errorf("unexpected username: want 'otheruser': got '%s'", username)
+	}
+}
+
+func TestSecretsManager_Retrieve_Version(t *testing.T) {
+	svc := &secretsManagerMock{
+		secrets: map[string]string{
+			"MyCredentials": `{"username":"smuser","password":"smpassword"}`,
+			"MyUsername":    "otheruser",
+		},
+		versions: make(map[string]string),
+	}
+
+	s := &SecretsManager{SecretID: "MyCredentials", UsernameID: "MyUsername", VersionStage: "AWSPREVIOUS", svc: svc}
+
+	if _, _, err := s.Retrieve(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The version only applies to the secretid secret
+	if got := svc.versions["MyCredentials"]; got != "AWSPREVIOUS" {
+		t.Errorf("unexpected version stage for the secretid secret: want 'AWSPREVIOUS': got '%s'", got)
+	}
+	if got := svc.versions["MyUsername"]; got != "" {
+		t.Errorf("unexpected version stage for the usernameid secret: want none: got '%s'", got)
+	}
+}
+
+func TestEnvStruct(t *testing.T) {
+	var i interface{} = EnvStruct()
+
+	if _, ok := i.(*Env); !ok {
+		t.Errorf("EnvStruct return value cannot be cast to a Env struct")
+	}
+}
+
+func TestEnv_Validate(t *testing.T) {
+	if err := (&Env{}).Validate(); err == nil {
+		t.Errorf("no error returned when usernamevar and passwordvar are empty")
+	}
+
+	if err := (&Env{PasswordVar: "RDP_PASSWORD"}).Validate(); err != nil {
+		t.Errorf("unexpected error returned when passwordvar is set: %s", err)
+	}
+}
+
+func TestEnv_Retrieve(t *testing.T) {
+	if err := os.Setenv("RUNRDP_TEST_USERNAME", "envuser"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("RUNRDP_TEST_USERNAME")
+
+	username, password, err := (&Env{UsernameVar: "RUNRDP_TEST_USERNAME"}).Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if username != "envuser" || password != "" {
+		t.Errorf("unexpected credentials: want 'envuser', '': got '%s', '%s'", username, password)
+	}
+
+	if _, _, err := (&Env{PasswordVar: "RUNRDP_TEST_UNSET"}).Retrieve(); err == nil {
+		t.Errorf("no error returned when the password variable is not set")
+	}
+}
+
+func TestVaultStruct(t *testing.T) {
+	var i interface{} = VaultStruct()
+
+	if _, ok := i.(*Vault); !ok {
+		t.Errorf("VaultStruct return value cannot be cast to a Vault struct")
+	}
+}
+
+func TestVault_Validate(t *testing.T) {
+	for _, v := range []*Vault{
+		{},
+		{Path: "rdp/web", KVVersion: 3},
+		{Path: "rdp/web", RoleID: "myrole"},
+		{Path: "rdp/web", Token: "s.token", RoleID: "myrole", SecretID: "mysecret"},
+	} {
+		if err := v.Validate(); err == nil {
+			t.Errorf("no error returned for invalid config %+v", v)
+		}
+	}
+
+	if err := (&Vault{Path: "rdp/web", RoleID: "myrole", SecretID: "mysecret"}).Validate(); err != nil {
+		t.Errorf("unexpected error returned for valid config: %s", err)
+	}
+}
+
+func TestVault_Retrieve(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/rdp/web" || r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"user":"vaultuser","pass":"vaultpassword"}}`))
+	}))
+	defer s.Close()
+
+	v := &Vault{Server: s.URL, Mount: "kv", Path: "rdp/web", KVVersion: 1, Token: "s.token",
+		UsernameKey: "user", PasswordKey: "pass"}
+
+	username, password, err := v.Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if username != "vaultuser" || password != "vaultpassword" {
+		t.Errorf("unexpected credentials: got '%s', '%s'", username, password)
+	}
+
+	v.PasswordKey = "missing"
+	if _, _, err := v.Retrieve(); err == nil {
+		t.Errorf("no error returned when the secret has no password key")
+	}
+}
+
+func TestSecretServerStruct(t *testing.T) {
+	var i interface{} = SecretServerStruct()
+
+	if _, ok := i.(*SecretServer); !ok {
+		t.Errorf("SecretServerStruct return value cannot be cast to a SecretServer struct")
+	}
+}
+
+func TestSecretServer_Validate(t *testing.T) {
+	for _, s := range []*SecretServer{
+		{SecretID: 1},
+		{Server: "https://example.com/SecretServer", Tenant: "example", SecretID: 1},
+		{Server: "https://example.com/SecretServer"},
+		{Server: "https://example.com/SecretServer", SecretID: 1, SecretName: "web"},
+		{Server: "https://example.com/SecretServer", SecretID: 1, FolderID: 10},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("no error returned for invalid config %+v", s)
+		}
+	}
+
+	if err := (&SecretServer{Tenant: "example", SecretName: "web", FolderID: 10}).Validate(); err != nil {
+		t.Errorf("unexpected error returned for valid config: %s", err)
+	}
+}
+
+func TestParameterStoreStruct(t *testing.T) {
+	var i interface{} = ParameterStoreStruct()
+
+	if _, ok := i.(*ParameterStore); !ok {
+		t.Errorf("ParameterStoreStruct return value cannot be cast to a ParameterStore struct")
+	}
+}
+
+func TestParameterStore_Validate(t *testing.T) {
+	if err := (&ParameterStore{}).Validate(); err == nil {
+		t.Errorf("no error returned when usernameparam and passwordparam are empty")
+	}
+
+	if err := (&ParameterStore{PasswordParam: "/rdp/password"}).Validate(); err != nil {
+		t.Errorf("unexpected error returned when passwordparam is set: %s", err)
+	}
+}
+
+type ssmMock struct {
+	ssmiface.SSMAPI
+	parameters map[string]string
+}
+
+func (s *ssmMock) GetParameter(i *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	value := s.parameters[*i.Name]
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: i.Name, Value: &value}}, nil
+}
+
+func TestParameterStore_Retrieve(t *testing.T) {
+	p := &ParameterStore{PasswordParam: "/rdp/password", svc: &ssmMock{parameters: map[string]string{
+		"/rdp/password": "ssmpassword",
+	}}}
+
+	username, password, err := p.Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if username != "" || password != "ssmpassword" {
+		t.Errorf("unexpected credentials: want '', 'ssmpassword': got '%s', '%s'", username, password)
+	}
+}
+
+func TestKeyringStruct(t *testing.T) {
+	var i interface{} = KeyringStruct()
+
+	if _, ok := i.(*Keyring); !ok {
+		t.Errorf("KeyringStruct return value cannot be cast to a Keyring struct")
+	}
+
+	if _, ok := i.(Named); !ok {
+		t.Errorf("Keyring does not implement Named")
+	}
+}
+
+func TestKeyring_Account(t *testing.T) {
+	k := &Keyring{}
+	k.SetName("mycred")
+
+	if k.service() != "runrdp" || k.account() != "mycred" {
+		t.Errorf("unexpected default service and account: %s/%s", k.service(), k.account())
+	}
+
+	k.Account = "other"
+	if k.account() != "other" {
+		t.Errorf("unexpected account: want 'other': got '%s'", k.account())
+	}
 }
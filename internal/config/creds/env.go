@@ -0,0 +1,55 @@
+package creds
+
+import (
+	"fmt"
+	"os"
+)
+
+// EnvStruct a struct of type creds.Env.
+func EnvStruct() interface{} {
+	return &Env{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (e *Env) Validate() error {
+	if e.UsernameVar == "" && e.PasswordVar == "" {
+		return fmt.Errorf("either usernamevar or passwordvar must be set")
+	}
+
+	return nil
+}
+
+// Env implements Cred and retrieves a username and password from environment variables.
+type Env struct {
+	UsernameVar string
+	PasswordVar string
+}
+
+// Retrieve returns the values of the configured environment variables or empty strings if the variable names were not
+// set. An error is returned if a configured variable is not set in the environment.
+func (e *Env) Retrieve() (string, string, error) {
+	username, err := envValue(e.UsernameVar)
+	if err != nil {
+		return "", "", fmt.Errorf("retrieving username: %s", err)
+	}
+
+	password, err := envValue(e.PasswordVar)
+	if err != nil {
+		return "", "", fmt.Errorf("retrieving password: %s", err)
+	}
+
+	return username, password, nil
+}
+
+func envValue(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
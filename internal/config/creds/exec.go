@@ -0,0 +1,161 @@
+package creds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const defaultExecTimeout = 30
+
+// ExecStruct a struct of type creds.Exec.
+func ExecStruct() interface{} {
+	return &Exec{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (e *Exec) Validate() error {
+	if e.Command == "" {
+		return fmt.Errorf("command must be set")
+	}
+
+	if e.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	for _, v := range e.Env {
+		if !strings.Contains(v, "=") {
+			return fmt.Errorf("env item '%s' must be in the form NAME=value", v)
+		}
+	}
+
+	return nil
+}
+
+// Exec implements Cred and runs a command which writes a username and password to stdout, either as a JSON object
+// with username and password keys or as two lines with the username first. This allows any password manager with a
+// command line interface to be used.
+type Exec struct {
+	Command string   // Name or path of the program
+	Args    []string // Arguments passed to the program
+	Timeout int      // Seconds to wait for the program to exit, 30 if 0
+	PassEnv []string // Names of the environment variables passed to the program, all variables are passed if empty
+	Env     []string // Extra environment variables in the form NAME=value
+}
+
+// Retrieve runs the command and returns the username and password it writes to stdout. If the command fails, the
+// error includes its stderr.
+func (e *Exec) Retrieve() (string, string, error) {
+	command, err := homedir.Expand(e.Command)
+	if err != nil {
+		return "", "", err
+	}
+
+	timeout := time.Duration(e.Timeout) * time.Second
+	if e.Timeout == 0 {
+		timeout = defaultExecTimeout * time.Second
+	}
+
+	cmd := exec.Command(command, e.Args...)
+	cmd.Env = e.environment()
+	setProcessGroup(cmd)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return "", "", fmt.Errorf("%s: %w", e.Command, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-done:
+	case <-timer.C:
+		_ = killProcessGroup(cmd)
+
+		// Don't wait for the command to exit. A process it started which wasn't killed may hold stdout open.
+		return "", "", fmt.Errorf("%s: timed out after %s", e.Command, timeout)
+	}
+
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return "", "", fmt.Errorf("%s: %w: %s", e.Command, err, msg)
+		}
+
+		return "", "", fmt.Errorf("%s: %w", e.Command, err)
+	}
+
+	username, password, err := parseExecOutput(stdout.Bytes())
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", e.Command, err)
+	}
+
+	return username, password, nil
+}
+
+// environment returns the environment of the command.
+func (e *Exec) environment() []string {
+	if len(e.PassEnv) == 0 {
+		return append(os.Environ(), e.Env...)
+	}
+
+	env := make([]string, 0)
+	for _, name := range e.PassEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	return append(env, e.Env...)
+}
+
+// parseExecOutput returns the username and password in the output of a command.
+func parseExecOutput(out []byte) (string, string, error) {
+	trimmed := bytes.TrimSpace(out)
+
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var v struct {
+			Username *string `json:"username"`
+			Password *string `json:"password"`
+		}
+
+		if err := json.Unmarshal(trimmed, &v); err != nil {
+			return "", "", fmt.Errorf("parsing output as JSON: %w", err)
+		}
+
+		if v.Username == nil && v.Password == nil {
+			return "", "", fmt.Errorf("output JSON has no username or password key")
+		}
+
+		var username, password string
+		if v.Username != nil {
+			username = *v.Username
+		}
+
+		if v.Password != nil {
+			password = *v.Password
+		}
+
+		return username, password, nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n"), "\n")
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("expected a JSON object or 2 lines of output (username and password): got %d lines",
+			len(lines))
+	}
+
+	return lines[0], lines[1], nil
+}
@@ -0,0 +1,157 @@
+package creds
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExecHelperProcess isn't a real test. It is run by helperExec as the command of an Exec cred and behaves as
+// described by RUNRDP_TEST_EXEC.
+func TestExecHelperProcess(t *testing.T) {
+	mode, ok := os.LookupEnv("RUNRDP_TEST_EXEC")
+	if !ok {
+		return
+	}
+
+	switch mode {
+	case "json":
+		fmt.Println(`{"username": "execuser", "password": "exec\"password"}`)
+	case "lines":
+		fmt.Print("execuser\r\nexecpassword\r\n")
+	case "env":
+		_, home := os.LookupEnv("RUNRDP_TEST_EXEC_HOME")
+		fmt.Printf("%s\n%t\n", os.Getenv("RUNRDP_TEST_EXEC_USER"), home)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "item not found")
+		os.Exit(1)
+	case "sleep":
+		time.Sleep(10 * time.Second)
+	case "grandchild":
+		// Like 'sh -c', start a process which shares stdout and wait for it
+		cmd := exec.Command(os.Args[0], "-test.run=TestExecHelperProcess")
+		cmd.Env = append(os.Environ(), "RUNRDP_TEST_EXEC=sleep")
+		cmd.Stdout = os.Stdout
+		_ = cmd.Run()
+	default:
+		fmt.Println("one line")
+	}
+
+	os.Exit(0)
+}
+
+// helperExec returns an Exec cred which runs TestExecHelperProcess in the given mode.
+func helperExec(mode string) *Exec {
+	return &Exec{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestExecHelperProcess"},
+		Env:     []string{"RUNRDP_TEST_EXEC=" + mode},
+	}
+}
+
+func TestExecStruct(t *testing.T) {
+	var i interface{} = ExecStruct()
+
+	if _, ok := i.(*Exec); !ok {
+		t.Errorf("ExecStruct return value cannot be cast to a Exec struct")
+	}
+}
+
+func TestExec_Validate(t *testing.T) {
+	for _, e := range []*Exec{
+		{},
+		{Command: "op", Timeout: -1},
+		{Command: "op", Env: []string{"NOVALUE"}},
+	} {
+		if err := e.Validate(); err == nil {
+			t.Errorf("no error returned for invalid fields %+v", e)
+		}
+	}
+
+	if err := (&Exec{Command: "op", Env: []string{"A=b"}}).Validate(); err != nil {
+		t.Errorf("unexpected error returned for valid fields: %s", err)
+	}
+}
+
+func TestExec_Retrieve(t *testing.T) {
+	for k, v := range map[string]string{"RUNRDP_TEST_EXEC_USER": "envuser", "RUNRDP_TEST_EXEC_HOME": "home"} {
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatal(err)
+		}
+
+		k := k
+		t.Cleanup(func() { _ = os.Unsetenv(k) })
+	}
+
+	filtered := helperExec("env")
+	filtered.PassEnv = []string{"RUNRDP_TEST_EXEC_USER", "RUNRDP_TEST_EXEC_MISSING"}
+
+	for _, tc := range []struct {
+		exec                       *Exec
+		wantUsername, wantPassword string
+	}{
+		{helperExec("json"), "execuser", `exec"password`},
+		{helperExec("lines"), "execuser", "execpassword"},
+		{helperExec("env"), "envuser", "true"},
+		{filtered, "envuser", "false"},
+	} {
+		username, password, err := tc.exec.Retrieve()
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			continue
+		}
+
+		if username != tc.wantUsername || password != tc.wantPassword {
+			t.Errorf("unexpected credentials: want '%s', '%s': got '%s', '%s'",
+				tc.wantUsername, tc.wantPassword, username, password)
+		}
+	}
+
+	if _, _, err := helperExec("fail").Retrieve(); err == nil || !strings.Contains(err.Error(), "item not found") {
+		t.Errorf("expected an error including stderr when the command fails: got %v", err)
+	}
+
+	if _, _, err := helperExec("oneline").Retrieve(); err == nil {
+		t.Errorf("expected an error when the output is one line")
+	}
+
+	sleep := helperExec("sleep")
+	sleep.Timeout = 1
+	if _, _, err := sleep.Retrieve(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error: got %v", err)
+	}
+
+	// A process started by the command holds stdout open after the command is killed
+	grandchild := helperExec("grandchild")
+	grandchild.Timeout = 1
+
+	start := time.Now()
+	if _, _, err := grandchild.Retrieve(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error with a grandchild process: got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %s for a grandchild process after the timeout", elapsed)
+	}
+}
+
+func TestParseExecOutput(t *testing.T) {
+	for _, out := range []string{
+		"",
+		"a\nb\nc\n",
+		`{"user": "a"}`,
+		`{"username": "a"`,
+	} {
+		if _, _, err := parseExecOutput([]byte(out)); err == nil {
+			t.Errorf("expected an error parsing output %q", out)
+		}
+	}
+
+	username, password, err := parseExecOutput([]byte(` {"password": "p"}` + "\n"))
+	if err != nil || username != "" || password != "p" {
+		t.Errorf("unexpected result parsing JSON without a username: '%s', '%s', %v", username, password, err)
+	}
+}
@@ -0,0 +1,16 @@
+package creds
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes cmd start in a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// killProcessGroup kills the started command. Processes it started are not killed, but Retrieve doesn't wait for them.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
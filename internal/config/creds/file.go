@@ -0,0 +1,173 @@
+package creds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"filippo.io/age"
+	"github.com/danhale-git/runrdp/internal/config/creds/secretfile"
+	"github.com/mitchellh/go-homedir"
+)
+
+// secretFiles caches the parsed contents of credential files by path so each file is only decrypted once.
+var secretFiles = make(map[string]interface{})
+
+// FileStruct a struct of type creds.File.
+func FileStruct() interface{} {
+	return &File{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (f *File) Validate() error {
+	if f.Path == "" {
+		return fmt.Errorf("path must be set")
+	}
+
+	return nil
+}
+
+// File implements Cred and reads a username and password from a YAML or JSON file. The file may be plaintext, in which
+// case it must only be accessible by its owner, encrypted with age or encrypted with sops. Age files encrypted with a
+// passphrase prompt for it, otherwise they are decrypted with age identities. Sops files are decrypted by running sops.
+type File struct {
+	Path        string // Path of the file
+	Key         string // Dot separated path of the object holding the username and password, for example servers.web
+	UsernameKey string // Key of the username in the object, 'username' if empty
+	PasswordKey string // Key of the password in the object, 'password' if empty
+	Identity    string // Age identity file, SOPS_AGE_KEY_FILE or sops/age/keys.txt in the user config directory if empty. Passed to sops as SOPS_AGE_KEY_FILE
+	Sops        string // Name or path of the sops program, 'sops' if empty
+
+	run CommandRunner // Runs sops, runCommand if nil
+}
+
+// Retrieve reads and decrypts the file, then returns the username and password in the object at Key.
+func (f *File) Retrieve() (string, string, error) {
+	path, err := homedir.Expand(f.Path)
+	if err != nil {
+		return "", "", err
+	}
+
+	doc, ok := secretFiles[path]
+	if !ok {
+		if doc, err = f.read(path); err != nil {
+			return "", "", fmt.Errorf("%s: %w", path, err)
+		}
+
+		secretFiles[path] = doc
+	}
+
+	v, err := secretfile.Lookup(doc, f.Key)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	object, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return "", "", fmt.Errorf("%s: key '%s' is not an object", path, f.Key)
+	}
+
+	usernameKey := stringOrDefault(f.UsernameKey, "username")
+	passwordKey := stringOrDefault(f.PasswordKey, "password")
+
+	_, hasUsername := object[usernameKey]
+	_, hasPassword := object[passwordKey]
+	if !hasUsername && !hasPassword {
+		return "", "", fmt.Errorf("%s: key '%s' has no '%s' or '%s' key", path, f.Key, usernameKey, passwordKey)
+	}
+
+	username, err := secretfile.String(object[usernameKey])
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %s: %w", path, usernameKey, err)
+	}
+
+	password, err := secretfile.String(object[passwordKey])
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %s: %w", path, passwordKey, err)
+	}
+
+	return username, password, nil
+}
+
+// read returns the parsed and decrypted contents of the file.
+func (f *File) read(path string) (interface{}, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch secretfile.Detect(data) {
+	case secretfile.Age:
+		if data, err = f.decryptAge(path, data); err != nil {
+			return nil, err
+		}
+	case secretfile.Sops:
+		run := f.run
+		if run == nil {
+			run = runCommand
+		}
+
+		env := []string{}
+		if f.Identity != "" {
+			identityFile, err := homedir.Expand(f.Identity)
+			if err != nil {
+				return nil, err
+			}
+
+			env = append(env, "SOPS_AGE_KEY_FILE="+identityFile)
+		}
+
+		binary := stringOrDefault(f.Sops, "sops")
+		if data, err = run(binary, []string{"--decrypt", path}, env); err != nil {
+			return nil, fmt.Errorf("%s --decrypt: %w", binary, err)
+		}
+	default:
+		if err := secretfile.CheckPermissions(info); err != nil {
+			return nil, err
+		}
+	}
+
+	return secretfile.Parse(data)
+}
+
+// decryptAge decrypts an age file, prompting for the passphrase if it was encrypted with one.
+func (f *File) decryptAge(path string, data []byte) ([]byte, error) {
+	if secretfile.IsPassphraseEncrypted(data) {
+		passphrase, err := PromptPassword(fmt.Sprintf("Passphrase for %s: ", path), false)
+		if err != nil {
+			return nil, err
+		}
+
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		return secretfile.DecryptAge(data, identity)
+	}
+
+	identityFile := f.Identity
+	if identityFile == "" {
+		var err error
+		if identityFile, err = secretfile.DefaultIdentityFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	identityFile, err := homedir.Expand(identityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := secretfile.ReadIdentities(identityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return secretfile.DecryptAge(data, identities...)
+}
@@ -0,0 +1,233 @@
+package creds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/mitchellh/go-homedir"
+)
+
+const testSecretFile = `servers:
+  web:
+    username: webuser
+    password: webpassword
+  sql:
+    login: sa
+    secret: 1234
+`
+
+// writeAgeFile writes testSecretFile encrypted to the given recipient and returns its path.
+func writeAgeFile(t *testing.T, r age.Recipient) string {
+	var b bytes.Buffer
+	a := armor.NewWriter(&b)
+
+	w, err := age.Encrypt(a, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte(testSecretFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml.age")
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func resetSecretFiles(t *testing.T) {
+	secretFiles = make(map[string]interface{})
+	t.Cleanup(func() { secretFiles = make(map[string]interface{}) })
+}
+
+func TestFileStruct(t *testing.T) {
+	var i interface{} = FileStruct()
+
+	if _, ok := i.(*File); !ok {
+		t.Errorf("FileStruct return value cannot be cast to a File struct")
+	}
+}
+
+func TestFile_Validate(t *testing.T) {
+	if err := (&File{}).Validate(); err == nil {
+		t.Errorf("no error returned when path is empty")
+	}
+
+	if err := (&File{Path: "secrets.yaml"}).Validate(); err != nil {
+		t.Errorf("unexpected error returned when path is set: %s", err)
+	}
+}
+
+func TestFile_Retrieve(t *testing.T) {
+	resetSecretFiles(t)
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "secrets.yaml")
+	if err := ioutil.WriteFile(plain, []byte(testSecretFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identityFile := filepath.Join(dir, "keys.txt")
+	if err := ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := writeAgeFile(t, identity.Recipient())
+
+	for _, tc := range []struct {
+		file                       *File
+		wantUsername, wantPassword string
+	}{
+		{&File{Path: plain, Key: "servers.web"}, "webuser", "webpassword"},
+		{&File{Path: plain, Key: "servers.sql", UsernameKey: "login", PasswordKey: "secret"}, "sa", "1234"},
+		{&File{Path: encrypted, Key: "servers.web", Identity: identityFile}, "webuser", "webpassword"},
+	} {
+		username, password, err := tc.file.Retrieve()
+		if err != nil {
+			t.Errorf("unexpected error retrieving %+v: %s", tc.file, err)
+			continue
+		}
+
+		if username != tc.wantUsername || password != tc.wantPassword {
+			t.Errorf("unexpected credentials: want '%s', '%s': got '%s', '%s'",
+				tc.wantUsername, tc.wantPassword, username, password)
+		}
+	}
+
+	for _, f := range []*File{
+		{Path: plain, Key: "servers.missing"},
+		{Path: plain, Key: "servers.web.username"},
+		{Path: plain},
+	} {
+		if _, _, err := f.Retrieve(); err == nil {
+			t.Errorf("expected error retrieving %+v", f)
+		}
+	}
+}
+
+func TestFile_RetrieveRefusesReadablePlaintext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+
+	resetSecretFiles(t)
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := ioutil.WriteFile(path, []byte(testSecretFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := (&File{Path: path, Key: "servers.web"}).Retrieve()
+	if err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("expected error for a plaintext file readable by other users: got %v", err)
+	}
+}
+
+func TestFile_RetrievePassphrase(t *testing.T) {
+	resetSecretFiles(t)
+
+	r, err := age.NewScryptRecipient("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetWorkFactor(10)
+
+	path := writeAgeFile(t, r)
+
+	calls := fakeTerminal(t, true, "wrong", "passphrase")
+
+	f := &File{Path: path, Key: "servers.web"}
+	if _, _, err := f.Retrieve(); err == nil {
+		t.Errorf("expected error with the wrong passphrase")
+	}
+
+	for i := 0; i < 2; i++ {
+		username, password, err := f.Retrieve()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if username != "webuser" || password != "webpassword" {
+			t.Errorf("unexpected credentials: want 'webuser', 'webpassword': got '%s', '%s'", username, password)
+		}
+	}
+
+	if *calls != 2 {
+		t.Errorf("expected the passphrase to be prompted for until the file was decrypted: got %d prompts", *calls)
+	}
+}
+
+func TestFile_RetrieveSops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	encrypted := `{"web": {"username": "ENC[AES256_GCM,data:x]", "password": "ENC[AES256_GCM,data:y]"},
+		"sops": {"mac": "ENC[AES256_GCM,data:z]", "version": "3.7.1"}}`
+	if err := ioutil.WriteFile(path, []byte(encrypted), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The identity is passed to sops so it decrypts with the same key as age files
+	for identity, wantEnv := range map[string]string{
+		"":                       "",
+		"/keys/age.txt":          "SOPS_AGE_KEY_FILE=/keys/age.txt",
+		"~/.config/age/keys.txt": "SOPS_AGE_KEY_FILE=" + filepath.Join(home, ".config/age/keys.txt"),
+	} {
+		resetSecretFiles(t)
+
+		f := &File{Path: path, Key: "web", Sops: "/opt/sops", Identity: identity,
+			run: func(name string, args, env []string) ([]byte, error) {
+				if name != "/opt/sops" || len(args) != 2 || args[0] != "--decrypt" || args[1] != path {
+					return nil, errors.New("unexpected command")
+				}
+
+				if strings.Join(env, " ") != wantEnv {
+					return nil, fmt.Errorf("unexpected environment: want '%s': got '%s'", wantEnv,
+						strings.Join(env, " "))
+				}
+
+				return []byte(`{"web": {"username": "sopsuser", "password": "sopspassword"}}`), nil
+			}}
+
+		username, password, err := f.Retrieve()
+		if err != nil {
+			t.Fatalf("unexpected error with identity '%s': %s", identity, err)
+		}
+
+		if username != "sopsuser" || password != "sopspassword" {
+			t.Errorf("unexpected credentials: want 'sopsuser', 'sopspassword': got '%s', '%s'", username, password)
+		}
+	}
+}
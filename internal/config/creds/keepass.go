@@ -0,0 +1,106 @@
+package creds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/danhale-git/runrdp/internal/config/creds/keepass"
+	"github.com/mitchellh/go-homedir"
+)
+
+// keePassPasswords caches master passwords by database path so each database is only unlocked with a prompted password
+// once.
+var keePassPasswords = make(map[string]string)
+
+// KeePassStruct a struct of type creds.KeePass.
+func KeePassStruct() interface{} {
+	return &KeePass{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (k *KeePass) Validate() error {
+	if k.File == "" {
+		return fmt.Errorf("file must be set")
+	}
+
+	if (k.Entry == "") == (k.UUID == "") {
+		return fmt.Errorf("exactly one of entry or uuid must be set")
+	}
+
+	if k.NoPassword && k.KeyFile == "" {
+		return fmt.Errorf("keyfile must be set when nopassword is true")
+	}
+
+	return nil
+}
+
+// KeePass implements Cred and retrieves the UserName and Password fields of an entry in a KeePass database (KDBX 3.1
+// or 4). The database is unlocked with a master password, which is prompted for, and/or a key file.
+type KeePass struct {
+	File       string // Path of the .kdbx database
+	KeyFile    string // Path of the key file, if the database uses one
+	NoPassword bool   // Unlock the database with the key file only, without prompting for a master password
+	Entry      string // Path of the entry below the root group, for example Servers/web
+	UUID       string // UUID of the entry, as shown in KeePass
+}
+
+// Retrieve opens the database, prompting for the master password if required, and returns the entry's username and
+// password.
+func (k *KeePass) Retrieve() (string, string, error) {
+	file, err := homedir.Expand(k.File)
+	if err != nil {
+		return "", "", err
+	}
+
+	var keyFile []byte
+	if k.KeyFile != "" {
+		path, err := homedir.Expand(k.KeyFile)
+		if err != nil {
+			return "", "", err
+		}
+
+		if keyFile, err = ioutil.ReadFile(path); err != nil {
+			return "", "", fmt.Errorf("reading key file: %w", err)
+		}
+	}
+
+	password, ok := keePassPasswords[file]
+	if !ok && !k.NoPassword {
+		if password, err = PromptPassword(fmt.Sprintf("Master password for %s: ", file), false); err != nil {
+			return "", "", err
+		}
+	}
+
+	key, err := keepass.CompositeKey(password, !k.NoPassword, keyFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	db, err := keepass.Open(f, key)
+	if err != nil {
+		return "", "", fmt.Errorf("opening %s: %w", file, err)
+	}
+
+	if !k.NoPassword {
+		keePassPasswords[file] = password
+	}
+
+	var entry *keepass.Entry
+	if k.UUID != "" {
+		entry, err = db.EntryByUUID(k.UUID)
+	} else {
+		entry, err = db.EntryByPath(k.Entry)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", file, err)
+	}
+
+	return entry.Fields["UserName"], entry.Fields["Password"], nil
+}
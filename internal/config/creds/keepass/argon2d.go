@@ -0,0 +1,294 @@
+package keepass
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Argon2d is the default key derivation function of KeePass 2.x and KeePassXC, but golang.org/x/crypto/argon2 only
+// implements Argon2i and Argon2id. This is an implementation of Argon2d version 0x13 as described in RFC 9106. It
+// follows the generic implementation in golang.org/x/crypto/argon2, without the data independent addressing used by
+// the other variants.
+
+const (
+	argon2Version    = 0x13
+	argon2dType      = 0
+	argon2BlockWords = 128 // Length of a block in 64 bit words
+	argon2SyncPoints = 4   // Number of slices in each pass
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+// argon2dKey derives a key of length keyLen from the password, salt, secret and associated data with the given number
+// of passes over memory KiB of memory, split into the given number of lanes which are processed in parallel.
+func argon2dKey(password, salt, secret, data []byte, passes, memory uint32, lanes uint8, keyLen uint32) []byte {
+	if passes < 1 {
+		passes = 1
+	}
+
+	if lanes < 1 {
+		lanes = 1
+	}
+
+	threads := uint32(lanes)
+
+	h0 := argon2InitHash(password, salt, secret, data, passes, memory, threads, keyLen)
+
+	memory = memory / (argon2SyncPoints * threads) * (argon2SyncPoints * threads)
+	if memory < 2*argon2SyncPoints*threads {
+		memory = 2 * argon2SyncPoints * threads
+	}
+
+	blocks := argon2InitBlocks(&h0, memory, threads)
+	argon2dProcessBlocks(blocks, passes, memory, threads)
+
+	return argon2ExtractKey(blocks, memory, threads, keyLen)
+}
+
+// argon2InitHash returns the initial 64 byte hash H0, followed by 8 bytes of space for the block and lane indexes.
+func argon2InitHash(password, salt, secret, data []byte, passes, memory, threads, keyLen uint32) [blake2b.Size + 8]byte {
+	var h0 [blake2b.Size + 8]byte
+
+	b2, _ := blake2b.New512(nil)
+
+	var params [24]byte
+	binary.LittleEndian.PutUint32(params[0:4], threads)
+	binary.LittleEndian.PutUint32(params[4:8], keyLen)
+	binary.LittleEndian.PutUint32(params[8:12], memory)
+	binary.LittleEndian.PutUint32(params[12:16], passes)
+	binary.LittleEndian.PutUint32(params[16:20], argon2Version)
+	binary.LittleEndian.PutUint32(params[20:24], argon2dType)
+	_, _ = b2.Write(params[:])
+
+	var length [4]byte
+	for _, b := range [][]byte{password, salt, secret, data} {
+		binary.LittleEndian.PutUint32(length[:], uint32(len(b)))
+		_, _ = b2.Write(length[:])
+		_, _ = b2.Write(b)
+	}
+
+	b2.Sum(h0[:0])
+
+	return h0
+}
+
+// argon2InitBlocks allocates the memory and fills the first two blocks of each lane from H0.
+func argon2InitBlocks(h0 *[blake2b.Size + 8]byte, memory, threads uint32) []argon2Block {
+	var b [1024]byte
+
+	blocks := make([]argon2Block, memory)
+
+	for lane := uint32(0); lane < threads; lane++ {
+		j := lane * (memory / threads)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+
+		for i := uint32(0); i < 2; i++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], i)
+			argon2Hash(b[:], h0[:])
+
+			for k := range blocks[j+i] {
+				blocks[j+i][k] = binary.LittleEndian.Uint64(b[k*8:])
+			}
+		}
+	}
+
+	return blocks
+}
+
+// argon2dProcessBlocks fills memory for each pass. The segments of each slice are filled in parallel.
+func argon2dProcessBlocks(blocks []argon2Block, passes, memory, threads uint32) {
+	laneLength := memory / threads
+	segmentLength := laneLength / argon2SyncPoints
+
+	processSegment := func(pass, slice, lane uint32, wg *sync.WaitGroup) {
+		defer wg.Done()
+
+		index := uint32(0)
+		if pass == 0 && slice == 0 {
+			index = 2 // The first two blocks have already been filled
+		}
+
+		offset := lane*laneLength + slice*segmentLength + index
+		for index < segmentLength {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += laneLength // Last block in the lane
+			}
+
+			// Argon2d addresses the reference block with the first word of the previous block
+			ref := argon2IndexAlpha(blocks[prev][0], laneLength, segmentLength, threads, pass, slice, lane, index)
+			argon2ProcessBlock(&blocks[offset], &blocks[prev], &blocks[ref])
+
+			index, offset = index+1, offset+1
+		}
+	}
+
+	for pass := uint32(0); pass < passes; pass++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+
+			for lane := uint32(0); lane < threads; lane++ {
+				wg.Add(1)
+				go processSegment(pass, slice, lane, &wg)
+			}
+
+			wg.Wait()
+		}
+	}
+}
+
+// argon2ExtractKey hashes the XOR of the last block of each lane to a key of length keyLen.
+func argon2ExtractKey(blocks []argon2Block, memory, threads, keyLen uint32) []byte {
+	laneLength := memory / threads
+
+	for lane := uint32(0); lane < threads-1; lane++ {
+		for i, v := range blocks[lane*laneLength+laneLength-1] {
+			blocks[memory-1][i] ^= v
+		}
+	}
+
+	var b [1024]byte
+	for i, v := range blocks[memory-1] {
+		binary.LittleEndian.PutUint64(b[i*8:], v)
+	}
+
+	key := make([]byte, keyLen)
+	argon2Hash(key, b[:])
+
+	return key
+}
+
+// argon2IndexAlpha returns the index of the reference block for the block at index in the given segment.
+func argon2IndexAlpha(rand uint64, laneLength, segmentLength, threads, pass, slice, lane, index uint32) uint32 {
+	refLane := uint32(rand>>32) % threads
+	if pass == 0 && slice == 0 {
+		refLane = lane
+	}
+
+	m, s := 3*segmentLength, ((slice+1)%argon2SyncPoints)*segmentLength
+	if lane == refLane {
+		m += index
+	}
+
+	if pass == 0 {
+		m, s = slice*segmentLength, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+
+	if index == 0 || lane == refLane {
+		m--
+	}
+
+	p := rand & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * uint64(m)) >> 32
+
+	return refLane*laneLength + uint32((uint64(s)+uint64(m)-(p+1))%uint64(laneLength))
+}
+
+// argon2Hash is the variable length hash function H' of Argon2.
+func argon2Hash(out, in []byte) {
+	var b2 hash.Hash
+	if n := len(out); n < blake2b.Size {
+		b2, _ = blake2b.New(n, nil)
+	} else {
+		b2, _ = blake2b.New512(nil)
+	}
+
+	var buffer [blake2b.Size]byte
+	binary.LittleEndian.PutUint32(buffer[:4], uint32(len(out)))
+	_, _ = b2.Write(buffer[:4])
+	_, _ = b2.Write(in)
+
+	if len(out) <= blake2b.Size {
+		b2.Sum(out[:0])
+		return
+	}
+
+	outLen := len(out)
+	b2.Sum(buffer[:0])
+	b2.Reset()
+	copy(out, buffer[:32])
+	out = out[32:]
+
+	for len(out) > blake2b.Size {
+		_, _ = b2.Write(buffer[:])
+		b2.Sum(buffer[:0])
+		copy(out, buffer[:32])
+		out = out[32:]
+		b2.Reset()
+	}
+
+	if outLen%blake2b.Size > 0 {
+		r := ((outLen + 31) / 32) - 2
+		b2, _ = blake2b.New(outLen-32*r, nil)
+	}
+
+	_, _ = b2.Write(buffer[:])
+	b2.Sum(out[:0])
+}
+
+// argon2ProcessBlock applies the compression function G to in1 and in2 and XORs the result into out, as version 0x13
+// does for every pass. Blocks are zero before the first pass, so the first pass overwrites them.
+func argon2ProcessBlock(out, in1, in2 *argon2Block) {
+	var r, t argon2Block
+	for i := range r {
+		r[i] = in1[i] ^ in2[i]
+	}
+
+	t = r
+
+	// Apply the permutation P to each row of 16 words, then to each column
+	for i := 0; i < argon2BlockWords; i += 16 {
+		blamka(&t, [16]int{i, i + 1, i + 2, i + 3, i + 4, i + 5, i + 6, i + 7,
+			i + 8, i + 9, i + 10, i + 11, i + 12, i + 13, i + 14, i + 15})
+	}
+
+	for i := 0; i < argon2BlockWords/8; i += 2 {
+		blamka(&t, [16]int{i, i + 1, 16 + i, 16 + i + 1, 32 + i, 32 + i + 1, 48 + i, 48 + i + 1,
+			64 + i, 64 + i + 1, 80 + i, 80 + i + 1, 96 + i, 96 + i + 1, 112 + i, 112 + i + 1})
+	}
+
+	for i := range t {
+		out[i] ^= r[i] ^ t[i]
+	}
+}
+
+// blamka applies the BlaMka round function to the 16 words of b at the given indexes.
+func blamka(b *argon2Block, idx [16]int) {
+	var v [16]uint64
+	for i, j := range idx {
+		v[i] = b[j]
+	}
+
+	blamkaG(&v, 0, 4, 8, 12)
+	blamkaG(&v, 1, 5, 9, 13)
+	blamkaG(&v, 2, 6, 10, 14)
+	blamkaG(&v, 3, 7, 11, 15)
+	blamkaG(&v, 0, 5, 10, 15)
+	blamkaG(&v, 1, 6, 11, 12)
+	blamkaG(&v, 2, 7, 8, 13)
+	blamkaG(&v, 3, 4, 9, 14)
+
+	for i, j := range idx {
+		b[j] = v[i]
+	}
+}
+
+// blamkaG is the BLAKE2b G function with the additions replaced by the multiplication hardened fBlaMka.
+func blamkaG(v *[16]uint64, a, b, c, d int) {
+	v[a] += v[b] + 2*uint64(uint32(v[a]))*uint64(uint32(v[b]))
+	v[d] = bits.RotateLeft64(v[d]^v[a], -32)
+	v[c] += v[d] + 2*uint64(uint32(v[c]))*uint64(uint32(v[d]))
+	v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+	v[a] += v[b] + 2*uint64(uint32(v[a]))*uint64(uint32(v[b]))
+	v[d] = bits.RotateLeft64(v[d]^v[a], -16)
+	v[c] += v[d] + 2*uint64(uint32(v[c]))*uint64(uint32(v[d]))
+	v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+}
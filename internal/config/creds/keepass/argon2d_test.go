@@ -0,0 +1,23 @@
+package keepass
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestArgon2dKey checks argon2dKey against the Argon2d test vector in RFC 9106 section 5.1.
+func TestArgon2dKey(t *testing.T) {
+	want, _ := hex.DecodeString("512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb")
+
+	got := argon2dKey(
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 16),
+		bytes.Repeat([]byte{0x03}, 8),
+		bytes.Repeat([]byte{0x04}, 12),
+		3, 32, 4, 32)
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("unexpected Argon2d tag:\nwant %x\ngot  %x", want, got)
+	}
+}
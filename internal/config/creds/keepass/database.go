@@ -0,0 +1,292 @@
+package keepass
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20"
+)
+
+const (
+	streamNone     = 0
+	streamSalsa20  = 2
+	streamChaCha20 = 3
+)
+
+var salsa20Nonce = []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}
+
+// Database is the decrypted content of a KeePass database.
+type Database struct {
+	Root *Group
+}
+
+// Group is a group of entries, which may contain other groups.
+type Group struct {
+	UUID    string
+	Name    string
+	Groups  []*Group
+	Entries []*Entry
+}
+
+// Entry is a single entry in a database. Fields maps field names such as UserName and Password to their values.
+// Protected values are decrypted.
+type Entry struct {
+	UUID   string
+	Fields map[string]string
+}
+
+// Title returns the title of the entry.
+func (e *Entry) Title() string {
+	return e.Fields["Title"]
+}
+
+// EntryByPath returns the entry at the given path, which is the names of the groups below the root group and the
+// title of the entry separated by /, for example Servers/web. An error is returned if no entry or more than one entry
+// has the path.
+func (d *Database) EntryByPath(path string) (*Entry, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	groups := []*Group{d.Root}
+	for _, name := range parts[:len(parts)-1] {
+		next := make([]*Group, 0)
+		for _, g := range groups {
+			for _, child := range g.Groups {
+				if child.Name == name {
+					next = append(next, child)
+				}
+			}
+		}
+
+		groups = next
+	}
+
+	title := parts[len(parts)-1]
+	found := make([]*Entry, 0)
+	for _, g := range groups {
+		for _, e := range g.Entries {
+			if e.Title() == title {
+				found = append(found, e)
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("entry '%s' was not found", path)
+	case 1:
+		return found[0], nil
+	}
+
+	return nil, fmt.Errorf("%d entries have the path '%s', use the entry UUID instead", len(found), path)
+}
+
+// EntryByUUID returns the entry with the given UUID, as shown in KeePass (32 hexadecimal digits, dashes are allowed)
+// or as stored in the database (base64).
+func (d *Database) EntryByUUID(id string) (*Entry, error) {
+	want, err := normalizeUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var find func(g *Group) *Entry
+	find = func(g *Group) *Entry {
+		for _, e := range g.Entries {
+			if e.UUID == want {
+				return e
+			}
+		}
+
+		for _, child := range g.Groups {
+			if e := find(child); e != nil {
+				return e
+			}
+		}
+
+		return nil
+	}
+
+	if e := find(d.Root); e != nil {
+		return e, nil
+	}
+
+	return nil, fmt.Errorf("entry with UUID '%s' was not found", id)
+}
+
+// normalizeUUID returns the lower case hexadecimal form of a UUID in hexadecimal or base64.
+func normalizeUUID(id string) (string, error) {
+	if b, err := hex.DecodeString(strings.ReplaceAll(id, "-", "")); err == nil && len(b) == 16 {
+		return hex.EncodeToString(b), nil
+	}
+
+	if b, err := base64.StdEncoding.DecodeString(id); err == nil && len(b) == 16 {
+		return hex.EncodeToString(b), nil
+	}
+
+	return "", fmt.Errorf("'%s' is not a valid UUID", id)
+}
+
+// node is an element of the XML document.
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []*node    `xml:",any"`
+}
+
+func (n *node) child(name string) *node {
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (n *node) childText(name string) string {
+	if c := n.child(name); c != nil {
+		return c.Text
+	}
+
+	return ""
+}
+
+func (n *node) protected() bool {
+	for _, a := range n.Attrs {
+		if a.Name.Local == "Protected" && strings.EqualFold(a.Value, "true") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseXML parses the XML document of a database, decrypting protected values with the inner random stream.
+func parseXML(data []byte, streamID uint32, streamKey []byte) (*Database, error) {
+	var doc node
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing database XML: %w", err)
+	}
+
+	if err := unprotect(&doc, streamID, streamKey); err != nil {
+		return nil, err
+	}
+
+	root := doc.child("Root")
+	if root == nil || root.child("Group") == nil {
+		return nil, fmt.Errorf("database has no root group")
+	}
+
+	return &Database{Root: parseGroup(root.child("Group"))}, nil
+}
+
+// unprotect decrypts all protected values in the document. The inner random stream is applied to protected values
+// in the order they appear, so the values are concatenated and decrypted together.
+func unprotect(doc *node, streamID uint32, streamKey []byte) error {
+	values := make([]*node, 0)
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.protected() {
+			values = append(values, n)
+		}
+
+		for _, c := range n.Nodes {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	decoded := make([][]byte, len(values))
+	var all []byte
+
+	for i, v := range values {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v.Text))
+		if err != nil {
+			return fmt.Errorf("decoding protected value: %w", err)
+		}
+
+		decoded[i] = b
+		all = append(all, b...)
+	}
+
+	switch streamID {
+	case streamNone:
+	case streamSalsa20:
+		key := sha256.Sum256(streamKey)
+		salsa20.XORKeyStream(all, all, salsa20Nonce, &key)
+	case streamChaCha20:
+		sum := sha512.Sum512(streamKey)
+
+		c, err := chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:44])
+		if err != nil {
+			return err
+		}
+
+		c.XORKeyStream(all, all)
+	default:
+		return fmt.Errorf("unsupported inner random stream %d", streamID)
+	}
+
+	for i, v := range values {
+		v.Text = string(all[:len(decoded[i])])
+		all = all[len(decoded[i]):]
+	}
+
+	return nil
+}
+
+func parseGroup(n *node) *Group {
+	g := &Group{
+		UUID: uuidText(n.childText("UUID")),
+		Name: n.childText("Name"),
+	}
+
+	for _, c := range n.Nodes {
+		switch c.XMLName.Local {
+		case "Group":
+			g.Groups = append(g.Groups, parseGroup(c))
+		case "Entry":
+			g.Entries = append(g.Entries, parseEntry(c))
+		}
+	}
+
+	return g
+}
+
+// parseEntry returns the current values of an entry. History is ignored.
+func parseEntry(n *node) *Entry {
+	e := &Entry{
+		UUID:   uuidText(n.childText("UUID")),
+		Fields: make(map[string]string),
+	}
+
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == "String" {
+			e.Fields[c.childText("Key")] = c.childText("Value")
+		}
+	}
+
+	return e
+}
+
+// uuidText returns the lower case hexadecimal form of a base64 UUID from the XML document.
+func uuidText(s string) string {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
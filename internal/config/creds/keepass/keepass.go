@@ -0,0 +1,521 @@
+package keepass
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/twofish"
+)
+
+// ErrInvalidKey is returned when a database can't be decrypted with the given key.
+var ErrInvalidKey = errors.New("invalid master password or key file")
+
+const (
+	signature1        = 0x9AA2D903
+	signature2        = 0xB54BFB67
+	headerEnd         = 0
+	headerCipherID    = 2
+	headerCompression = 3
+	headerMasterSeed  = 4
+	headerTransSeed   = 5 // Version 3 only
+	headerTransRounds = 6 // Version 3 only
+	headerIV          = 7
+	headerStreamKey   = 8 // Version 3 only
+	headerStartBytes  = 9 // Version 3 only
+	headerStreamID    = 10
+	headerKdfParams   = 11 // Version 4 only
+	innerHeaderEnd    = 0
+	innerStreamID     = 1
+	innerStreamKey    = 2
+)
+
+var (
+	cipherAES      = mustUUID("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustUUID("d6038a2b8b6f4cb5a524339a31dbb59a")
+	cipherTwofish  = mustUUID("ad68f29f576f4bb9a36ad47af965346c")
+	kdfAES         = mustUUID("c9d9f39a628a4460bf740d08c18a4fea") // AES-KDF as identified by KeePass, and by KeePassXC for version 3.1
+	kdfAES4        = mustUUID("7c02bb8279a74ac0927d114a00648238") // AES-KDF as identified by KeePassXC in version 4 databases
+	kdfArgon2d     = mustUUID("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id    = mustUUID("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// header is the outer header of a database.
+type header struct {
+	version    uint16 // Major version
+	raw        []byte // Bytes of the header, used to verify it in version 4
+	fields     map[byte][]byte
+	streamID   uint32 // Inner random stream ID, read from the inner header in version 4
+	streamKey  []byte
+	kdf        map[string][]byte // Version 4 only
+	compressed bool
+}
+
+// Open decrypts and parses a KeePass database in KDBX 3.1 or KDBX 4 format. compositeKey is returned by CompositeKey.
+// ErrInvalidKey is returned if the key is wrong.
+func Open(r io.Reader, compositeKey []byte) (*Database, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h, rest, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+
+	switch h.version {
+	case 3:
+		payload, err = decryptV3(h, rest, compositeKey)
+	case 4:
+		payload, err = decryptV4(h, rest, compositeKey)
+	default:
+		return nil, fmt.Errorf("unsupported KDBX version %d", h.version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if h.compressed {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing database: %w", err)
+		}
+
+		// Like KeePass, ignore anything after the gzip stream. Some writers pad ChaCha20 payloads as they do for AES.
+		gz.Multistream(false)
+
+		if payload, err = ioutil.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("decompressing database: %w", err)
+		}
+	}
+
+	if h.version == 4 {
+		if payload, err = readInnerHeader(h, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseXML(payload, h.streamID, h.streamKey)
+}
+
+// readHeader reads the outer header and returns the remaining data.
+func readHeader(data []byte) (*header, []byte, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:4]) != signature1 ||
+		binary.LittleEndian.Uint32(data[4:8]) != signature2 {
+		return nil, nil, fmt.Errorf("not a KeePass database")
+	}
+
+	h := &header{
+		version: binary.LittleEndian.Uint16(data[10:12]),
+		fields:  make(map[byte][]byte),
+	}
+
+	sizeLen := 2
+	if h.version >= 4 {
+		sizeLen = 4
+	}
+
+	pos := 12
+	for {
+		if pos+1+sizeLen > len(data) {
+			return nil, nil, fmt.Errorf("database header is truncated")
+		}
+
+		id := data[pos]
+		pos++
+
+		var size int
+		if sizeLen == 2 {
+			size = int(binary.LittleEndian.Uint16(data[pos:]))
+		} else {
+			size = int(binary.LittleEndian.Uint32(data[pos:]))
+		}
+		pos += sizeLen
+
+		if size < 0 || pos+size > len(data) {
+			return nil, nil, fmt.Errorf("database header is truncated")
+		}
+
+		h.fields[id] = data[pos : pos+size]
+		pos += size
+
+		if id == headerEnd {
+			break
+		}
+	}
+
+	h.raw = data[:pos]
+	h.compressed = len(h.fields[headerCompression]) == 4 &&
+		binary.LittleEndian.Uint32(h.fields[headerCompression]) == 1
+
+	if h.version == 3 {
+		if len(h.fields[headerStreamID]) != 4 {
+			return nil, nil, fmt.Errorf("database header has no inner random stream ID")
+		}
+
+		h.streamID = binary.LittleEndian.Uint32(h.fields[headerStreamID])
+		h.streamKey = h.fields[headerStreamKey]
+	}
+
+	if h.version >= 4 {
+		kdf, err := readVariantDictionary(h.fields[headerKdfParams])
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading key derivation parameters: %w", err)
+		}
+
+		h.kdf = kdf
+	}
+
+	return h, data[pos:], nil
+}
+
+// decryptV3 returns the decrypted payload of a version 3 database.
+func decryptV3(h *header, data, compositeKey []byte) ([]byte, error) {
+	if len(h.fields[headerTransRounds]) != 8 {
+		return nil, fmt.Errorf("database header has no transform rounds")
+	}
+
+	transformed, err := aesKDF(compositeKey, h.fields[headerTransSeed],
+		binary.LittleEndian.Uint64(h.fields[headerTransRounds]))
+	if err != nil {
+		return nil, err
+	}
+
+	key := sha256.Sum256(append(append([]byte{}, h.fields[headerMasterSeed]...), transformed...))
+
+	plain, err := decrypt(h.fields[headerCipherID], key[:], h.fields[headerIV], data)
+	if err != nil {
+		return nil, err
+	}
+
+	start := h.fields[headerStartBytes]
+	if len(plain) < len(start) || !bytes.Equal(plain[:len(start)], start) {
+		return nil, ErrInvalidKey
+	}
+
+	// The payload is split into blocks of [index][SHA-256 hash][size][data], ending with a block of size 0
+	var payload bytes.Buffer
+	pos := len(start)
+	for {
+		if pos+40 > len(plain) {
+			return nil, fmt.Errorf("database is truncated")
+		}
+
+		hash := plain[pos+4 : pos+36]
+		size := int(binary.LittleEndian.Uint32(plain[pos+36:]))
+		pos += 40
+
+		if size == 0 {
+			break
+		}
+
+		if pos+size > len(plain) {
+			return nil, fmt.Errorf("database is truncated")
+		}
+
+		block := plain[pos : pos+size]
+		if sum := sha256.Sum256(block); !bytes.Equal(sum[:], hash) {
+			return nil, fmt.Errorf("database is corrupt: block hash does not match")
+		}
+
+		payload.Write(block)
+		pos += size
+	}
+
+	return payload.Bytes(), nil
+}
+
+// decryptV4 returns the decrypted payload of a version 4 database.
+func decryptV4(h *header, data, compositeKey []byte) ([]byte, error) {
+	if len(data) < 64 {
+		return nil, fmt.Errorf("database is truncated")
+	}
+
+	if sum := sha256.Sum256(h.raw); !bytes.Equal(sum[:], data[:32]) {
+		return nil, fmt.Errorf("database is corrupt: header hash does not match")
+	}
+
+	transformed, err := deriveKey(h.kdf, compositeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := h.fields[headerMasterSeed]
+
+	hmacBase := sha512.Sum512(append(append(append([]byte{}, seed...), transformed...), 1))
+
+	headerMAC := hmac.New(sha256.New, blockKey(hmacBase[:], ^uint64(0)))
+	headerMAC.Write(h.raw)
+
+	if !hmac.Equal(headerMAC.Sum(nil), data[32:64]) {
+		return nil, ErrInvalidKey
+	}
+
+	// The encrypted payload is split into blocks of [HMAC-SHA-256][size][data], ending with a block of size 0
+	var encrypted bytes.Buffer
+	pos := 64
+	for i := uint64(0); ; i++ {
+		if pos+36 > len(data) {
+			return nil, fmt.Errorf("database is truncated")
+		}
+
+		mac := data[pos : pos+32]
+		size := int(binary.LittleEndian.Uint32(data[pos+32:]))
+
+		if size < 0 || pos+36+size > len(data) {
+			return nil, fmt.Errorf("database is truncated")
+		}
+
+		if !hmac.Equal(blockHMAC(hmacBase[:], i, data[pos+32:pos+36+size]), mac) {
+			return nil, fmt.Errorf("database is corrupt: block HMAC does not match")
+		}
+
+		if size == 0 {
+			break
+		}
+
+		encrypted.Write(data[pos+36 : pos+36+size])
+		pos += 36 + size
+	}
+
+	key := sha256.Sum256(append(append([]byte{}, seed...), transformed...))
+
+	return decrypt(h.fields[headerCipherID], key[:], h.fields[headerIV], encrypted.Bytes())
+}
+
+// blockKey returns the HMAC key of the version 4 block with the given index. The header uses the maximum index.
+func blockKey(hmacBase []byte, index uint64) []byte {
+	var i [8]byte
+	binary.LittleEndian.PutUint64(i[:], index)
+
+	key := sha512.Sum512(append(i[:], hmacBase...))
+
+	return key[:]
+}
+
+// blockHMAC returns the HMAC-SHA-256 of a version 4 block, where data is the block size followed by its contents.
+func blockHMAC(hmacBase []byte, index uint64, data []byte) []byte {
+	var i [8]byte
+	binary.LittleEndian.PutUint64(i[:], index)
+
+	mac := hmac.New(sha256.New, blockKey(hmacBase, index))
+	mac.Write(i[:])
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+// readInnerHeader reads the inner header of a version 4 database into h and returns the XML document which follows
+// it.
+func readInnerHeader(h *header, payload []byte) ([]byte, error) {
+	pos := 0
+	for {
+		if pos+5 > len(payload) {
+			return nil, fmt.Errorf("database inner header is truncated")
+		}
+
+		id := payload[pos]
+		size := int(binary.LittleEndian.Uint32(payload[pos+1:]))
+		pos += 5
+
+		if size < 0 || pos+size > len(payload) {
+			return nil, fmt.Errorf("database inner header is truncated")
+		}
+
+		value := payload[pos : pos+size]
+		pos += size
+
+		switch id {
+		case innerHeaderEnd:
+			return payload[pos:], nil
+		case innerStreamID:
+			if size != 4 {
+				return nil, fmt.Errorf("invalid inner random stream ID")
+			}
+
+			h.streamID = binary.LittleEndian.Uint32(value)
+		case innerStreamKey:
+			h.streamKey = value
+		}
+	}
+}
+
+// deriveKey transforms the composite key with the key derivation function described by the version 4 KDF parameters.
+func deriveKey(params map[string][]byte, compositeKey []byte) ([]byte, error) {
+	id := params["$UUID"]
+
+	switch {
+	case bytes.Equal(id, kdfAES[:]) || bytes.Equal(id, kdfAES4[:]):
+		if len(params["R"]) != 8 {
+			return nil, fmt.Errorf("invalid AES-KDF parameters")
+		}
+
+		return aesKDF(compositeKey, params["S"], binary.LittleEndian.Uint64(params["R"]))
+	case bytes.Equal(id, kdfArgon2id[:]) || bytes.Equal(id, kdfArgon2d[:]):
+		if len(params["I"]) != 8 || len(params["M"]) != 8 || len(params["P"]) != 4 {
+			return nil, fmt.Errorf("invalid Argon2 parameters")
+		}
+
+		if v := params["V"]; len(v) == 4 && binary.LittleEndian.Uint32(v) != argon2Version {
+			return nil, fmt.Errorf("unsupported Argon2 version %#x", binary.LittleEndian.Uint32(v))
+		}
+
+		iterations := uint32(binary.LittleEndian.Uint64(params["I"]))
+		memory := uint32(binary.LittleEndian.Uint64(params["M"]) / 1024)
+		parallelism := uint8(binary.LittleEndian.Uint32(params["P"]))
+
+		if bytes.Equal(id, kdfArgon2d[:]) {
+			return argon2dKey(compositeKey, params["S"], params["K"], params["A"], iterations, memory, parallelism,
+				32), nil
+		}
+
+		return argon2.IDKey(compositeKey, params["S"], iterations, memory, parallelism, 32), nil
+	}
+
+	return nil, fmt.Errorf("unknown key derivation function %x", id)
+}
+
+// aesKDF encrypts the key with AES-256 in ECB mode the given number of times and returns its SHA-256 hash.
+func aesKDF(key, seed []byte, rounds uint64) ([]byte, error) {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transform seed: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid composite key length %d", len(key))
+	}
+
+	k := append([]byte{}, key...)
+	for i := uint64(0); i < rounds; i++ {
+		block.Encrypt(k[:16], k[:16])
+		block.Encrypt(k[16:], k[16:])
+	}
+
+	sum := sha256.Sum256(k)
+
+	return sum[:], nil
+}
+
+// decrypt decrypts data with the cipher with the given UUID.
+func decrypt(cipherID, key, iv, data []byte) ([]byte, error) {
+	var block cipher.Block
+	var err error
+
+	switch {
+	case bytes.Equal(cipherID, cipherAES[:]):
+		block, err = aes.NewCipher(key)
+	case bytes.Equal(cipherID, cipherTwofish[:]):
+		block, err = twofish.NewCipher(key)
+	case bytes.Equal(cipherID, cipherChaCha20[:]):
+		c, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+
+		plain := make([]byte, len(data))
+		c.XORKeyStream(plain, data)
+
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("unknown cipher %x", cipherID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 || len(iv) != block.BlockSize() {
+		return nil, ErrInvalidKey
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// Remove PKCS #7 padding. Invalid padding means the key was wrong.
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > block.BlockSize() || pad > len(plain) {
+		return nil, ErrInvalidKey
+	}
+
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return nil, ErrInvalidKey
+		}
+	}
+
+	return plain[:len(plain)-pad], nil
+}
+
+// readVariantDictionary returns the values of a version 4 variant dictionary by key. Values are not converted from
+// their little endian encoding.
+func readVariantDictionary(data []byte) (map[string][]byte, error) {
+	if len(data) < 2 || data[1] != 1 {
+		return nil, fmt.Errorf("unsupported variant dictionary version")
+	}
+
+	values := make(map[string][]byte)
+
+	pos := 2
+	for {
+		if pos >= len(data) {
+			return nil, fmt.Errorf("variant dictionary is truncated")
+		}
+
+		if data[pos] == 0 {
+			return values, nil
+		}
+		pos++
+
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("variant dictionary is truncated")
+		}
+
+		keyLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+
+		if keyLen < 0 || pos+keyLen+4 > len(data) {
+			return nil, fmt.Errorf("variant dictionary is truncated")
+		}
+
+		key := string(data[pos : pos+keyLen])
+		pos += keyLen
+
+		valueLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+
+		if valueLen < 0 || pos+valueLen > len(data) {
+			return nil, fmt.Errorf("variant dictionary is truncated")
+		}
+
+		values[key] = data[pos : pos+valueLen]
+		pos += valueLen
+	}
+}
+
+func mustUUID(s string) [16]byte {
+	var id [16]byte
+
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		panic(fmt.Sprintf("invalid UUID %s", s))
+	}
+
+	copy(id[:], b)
+
+	return id
+}
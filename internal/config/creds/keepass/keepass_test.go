@@ -0,0 +1,267 @@
+package keepass
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regenerate the database fixtures in testdata")
+
+const testPassword = "correct horse battery staple"
+
+// testKeyFile is an XML version 2.0 key file as created by KeePass.
+var testKeyFile = filepath.Join("testdata", "test.keyx")
+
+func testRoot() *Group {
+	return &Group{
+		UUID: "00000000000000000000000000000001",
+		Name: "Root",
+		Entries: []*Entry{
+			{UUID: "0000000000000000000000000000000a", Fields: map[string]string{
+				"Title": "top", "UserName": "topuser", "Password": "toppass"}},
+		},
+		Groups: []*Group{
+			{
+				UUID: "00000000000000000000000000000002",
+				Name: "Servers",
+				Entries: []*Entry{
+					{UUID: "6f3e2b1c9a8d4e7f8a1b2c3d4e5f6a7b", Fields: map[string]string{
+						"Title": "web", "UserName": `CORP\admin`, "Password": "p<a>ss&word"}},
+					{UUID: "0000000000000000000000000000000b", Fields: map[string]string{
+						"Title": "sql", "UserName": "sa", "Password": "sqlpass"}},
+					{UUID: "0000000000000000000000000000000c", Fields: map[string]string{
+						"Title": "sql", "UserName": "sa2", "Password": "sqlpass2"}},
+				},
+			},
+		},
+	}
+}
+
+var fixtures = []struct {
+	file        string
+	db          testDatabase
+	usePassword bool
+	keyFile     bool
+}{
+	{"v3-aes-salsa20.kdbx", testDatabase{version: 3, cipher: cipherAES, kdf: kdfAES, rounds: 6000,
+		streamID: streamSalsa20}, true, false},
+	{"v4-chacha20-argon2id.kdbx", testDatabase{version: 4, cipher: cipherChaCha20, kdf: kdfArgon2id, rounds: 2,
+		streamID: streamChaCha20}, true, true},
+	{"v4-aes-aeskdf-keyfile.kdbx", testDatabase{version: 4, cipher: cipherAES, kdf: kdfAES, rounds: 6000,
+		streamID: streamChaCha20}, false, true},
+	// The defaults of KeePass 2.x and KeePassXC for new databases
+	{"v4-aes-argon2d.kdbx", testDatabase{version: 4, cipher: cipherAES, kdf: kdfArgon2d, rounds: 2,
+		streamID: streamChaCha20}, true, false},
+}
+
+func fixtureKey(t *testing.T, usePassword, keyFile bool) []byte {
+	var k []byte
+	if keyFile {
+		var err error
+		if k, err = ioutil.ReadFile(testKeyFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key, err := CompositeKey(testPassword, usePassword, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// libraryFixtures are written by github.com/tobischo/gokeepasslib, which doesn't share any code with this package or
+// its test writer. They are generated by the program in testdata/gokeepasslib. gokeepasslib doesn't support Argon2id.
+var libraryFixtures = []struct {
+	file        string
+	usePassword bool
+	keyFile     bool
+}{
+	{"gokeepasslib-v3-aes-salsa20.kdbx", true, false},
+	{"gokeepasslib-v4-aes-argon2d.kdbx", true, false},
+	{"gokeepasslib-v4-chacha20-argon2d-keyfile.kdbx", true, true},
+}
+
+func TestOpen(t *testing.T) {
+	for _, f := range fixtures {
+		path := filepath.Join("testdata", f.file)
+
+		if *update {
+			f.db.root = testRoot()
+			data := writeDatabase(t, f.db, fixtureKey(t, f.usePassword, f.keyFile))
+			if err := ioutil.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		testOpenFixture(t, f.file, fixtureKey(t, f.usePassword, f.keyFile))
+	}
+}
+
+func TestOpen_Library(t *testing.T) {
+	for _, f := range libraryFixtures {
+		testOpenFixture(t, f.file, fixtureKey(t, f.usePassword, f.keyFile))
+	}
+}
+
+// testOpenFixture opens the fixture file in testdata, which contains the groups and entries returned by testRoot, and
+// checks the entries can be found.
+func testOpenFixture(t *testing.T, file string, key []byte) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(bytes.NewReader(data), key)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %s", file, err)
+	}
+
+	for _, tc := range []struct {
+		path, username, password string
+	}{
+		{"Servers/web", `CORP\admin`, "p<a>ss&word"},
+		{"/Servers/web", `CORP\admin`, "p<a>ss&word"},
+		{"top", "topuser", "toppass"},
+	} {
+		e, err := db.EntryByPath(tc.path)
+		if err != nil {
+			t.Errorf("%s: unexpected error getting '%s': %s", file, tc.path, err)
+			continue
+		}
+
+		if e.Fields["UserName"] != tc.username || e.Fields["Password"] != tc.password {
+			t.Errorf("%s: unexpected credentials for '%s': %s %s", file, tc.path, e.Fields["UserName"],
+				e.Fields["Password"])
+		}
+	}
+
+	for _, id := range []string{
+		"6f3e2b1c-9a8d-4e7f-8a1b-2c3d4e5f6a7b",
+		"6F3E2B1C9A8D4E7F8A1B2C3D4E5F6A7B",
+		"bz4rHJqNTn+KGyw9Tl9qew==",
+	} {
+		e, err := db.EntryByUUID(id)
+		if err != nil {
+			t.Errorf("%s: unexpected error getting UUID %s: %s", file, id, err)
+			continue
+		}
+
+		if e.Title() != "web" {
+			t.Errorf("%s: expected UUID %s to be entry web: got %s", file, id, e.Title())
+		}
+	}
+
+	for _, p := range []string{"Servers/sql", "Servers/missing", "Missing/web", "web"} {
+		if _, err := db.EntryByPath(p); err == nil {
+			t.Errorf("%s: expected error getting '%s'", file, p)
+		}
+	}
+
+	if _, err := db.EntryByUUID("0000000000000000000000000000ffff"); err == nil {
+		t.Errorf("%s: expected error getting an entry which doesn't exist by UUID", file)
+	}
+
+	wrongKey, err := CompositeKey("wrong", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(bytes.NewReader(data), wrongKey); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("%s: expected ErrInvalidKey with the wrong key: got %v", file, err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	key := fixtureKey(t, true, false)
+
+	if _, err := Open(strings.NewReader("not a database"), key); err == nil {
+		t.Errorf("expected error opening a file which is not a database")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", fixtures[0].file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(bytes.NewReader(data[:len(data)/2]), key); err == nil {
+		t.Errorf("expected error opening a truncated database")
+	}
+
+	_, err = deriveKey(map[string][]byte{"$UUID": kdfArgon2d[:]}, key)
+	if err == nil || !strings.Contains(err.Error(), "invalid Argon2 parameters") {
+		t.Errorf("expected an error deriving an Argon2d key without parameters: got %v", err)
+	}
+}
+
+func TestDeriveKey_AESKDF(t *testing.T) {
+	key := fixtureKey(t, true, false)
+	seed := bytes.Repeat([]byte{1}, 32)
+
+	// KeePassXC identifies AES-KDF by a different UUID in version 4 databases
+	want, err := deriveKey(map[string][]byte{"$UUID": kdfAES[:], "S": seed, "R": le64(100)}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := deriveKey(map[string][]byte{"$UUID": kdfAES4[:], "S": seed, "R": le64(100)}, key)
+	if err != nil {
+		t.Fatalf("unexpected error with the KeePassXC AES-KDF UUID: %s", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("AES-KDF UUIDs derived different keys")
+	}
+}
+
+func TestCompositeKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 32)
+
+	for _, tc := range []struct {
+		name    string
+		keyFile []byte
+	}{
+		{"binary", key},
+		{"hex", []byte(strings.Repeat("ab", 32) + "\n")},
+		{"xml v1", []byte(`<KeyFile><Meta><Version>1.00</Version></Meta>` +
+			`<Key><Data>q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=</Data></Key></KeyFile>`)},
+		{"xml v2", []byte(`<KeyFile><Meta><Version>2.0</Version></Meta>` +
+			`<Key><Data>` + strings.Repeat("ABABABAB ", 8) + `</Data></Key></KeyFile>`)},
+	} {
+		got, err := keyFileKey(tc.keyFile)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+			continue
+		}
+
+		if !bytes.Equal(got, key) {
+			t.Errorf("%s: unexpected key %x", tc.name, got)
+		}
+	}
+
+	other := []byte("any other file")
+	if got, _ := keyFileKey(other); bytes.Equal(got, other) || len(got) != 32 {
+		t.Errorf("expected the key of other files to be their hash: got %x", got)
+	}
+
+	valid, err := ioutil.ReadFile(testKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keyFileKey(bytes.Replace(valid, []byte("49FC001C"), []byte("00000000"), 1)); err == nil {
+		t.Errorf("expected error when the XML key file hash does not match")
+	}
+
+	if _, err := CompositeKey("", false, nil); err == nil {
+		t.Errorf("expected error when neither a password nor key file is used")
+	}
+}
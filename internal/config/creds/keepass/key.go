@@ -0,0 +1,98 @@
+package keepass
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// CompositeKey returns the key used to unlock a database from a master password and the contents of a key file. The
+// password is not used if usePassword is false and the key file is not used if keyFile is nil.
+func CompositeKey(password string, usePassword bool, keyFile []byte) ([]byte, error) {
+	if !usePassword && keyFile == nil {
+		return nil, fmt.Errorf("a master password or key file is required")
+	}
+
+	h := sha256.New()
+
+	if usePassword {
+		p := sha256.Sum256([]byte(password))
+		h.Write(p[:])
+	}
+
+	if keyFile != nil {
+		k, err := keyFileKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		h.Write(k)
+	}
+
+	return h.Sum(nil), nil
+}
+
+// keyFileKey returns the 32 byte key in a key file. XML key files (version 1.0 and 2.0), 32 byte binary files and
+// 64 character hex files contain the key. The key of any other file is the SHA-256 hash of its contents.
+func keyFileKey(keyFile []byte) ([]byte, error) {
+	var x struct {
+		XMLName xml.Name `xml:"KeyFile"`
+		Meta    struct {
+			Version string `xml:"Version"`
+		} `xml:"Meta"`
+		Key struct {
+			Data struct {
+				Hash  string `xml:"Hash,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"Data"`
+		} `xml:"Key"`
+	}
+
+	if err := xml.Unmarshal(keyFile, &x); err == nil {
+		return xmlKeyFileKey(x.Meta.Version, x.Key.Data.Value, x.Key.Data.Hash)
+	}
+
+	if len(keyFile) == 32 {
+		return keyFile, nil
+	}
+
+	if trimmed := bytes.TrimSpace(keyFile); len(trimmed) == 64 {
+		if k, err := hex.DecodeString(string(trimmed)); err == nil {
+			return k, nil
+		}
+	}
+
+	k := sha256.Sum256(keyFile)
+
+	return k[:], nil
+}
+
+func xmlKeyFileKey(version, data, hash string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(version, "1."):
+		k, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file data: %w", err)
+		}
+
+		return k, nil
+	case strings.HasPrefix(version, "2."):
+		k, err := hex.DecodeString(strings.Join(strings.Fields(data), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file data: %w", err)
+		}
+
+		// The hash attribute is the first 4 bytes of the SHA-256 hash of the key, used to detect corrupt files
+		if sum := sha256.Sum256(k); hash != "" && !strings.EqualFold(hex.EncodeToString(sum[:4]), hash) {
+			return nil, fmt.Errorf("key file is corrupt: hash does not match data")
+		}
+
+		return k, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key file version '%s'", version)
+}
@@ -0,0 +1,11 @@
+module github.com/danhale-git/runrdp/internal/config/creds/keepass/testdata/gokeepasslib
+
+go 1.21.6
+
+require github.com/tobischo/gokeepasslib/v3 v3.5.3
+
+require (
+	github.com/tobischo/argon2 v0.1.0 // indirect
+	golang.org/x/crypto v0.18.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+)
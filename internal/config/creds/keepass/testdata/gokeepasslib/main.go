@@ -0,0 +1,194 @@
+// Command gokeepasslib writes the gokeepasslib-*.kdbx fixtures in the parent directory. The databases are written by
+// github.com/tobischo/gokeepasslib, an implementation of the KDBX format which is independent of the keepass package
+// and its test writer. It also checks that gokeepasslib can read the fixtures written by the test writer, except for
+// Argon2id which gokeepasslib doesn't support. It is a separate module so the library is not a dependency of runrdp.
+//
+// Run it from this directory with: go run .
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+const password = "correct horse battery staple"
+
+func main() {
+	keyFile, err := ioutil.ReadFile(filepath.Join("..", "test.keyx"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	withKeyFile, err := gokeepasslib.NewPasswordAndKeyDataCredentials(password, keyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range []struct {
+		file        string
+		db          *gokeepasslib.Database
+		credentials *gokeepasslib.DBCredentials
+	}{
+		{"gokeepasslib-v3-aes-salsa20.kdbx", v3(), gokeepasslib.NewPasswordCredentials(password)},
+		{"gokeepasslib-v4-aes-argon2d.kdbx", v4(gokeepasslib.CipherAES, 16),
+			gokeepasslib.NewPasswordCredentials(password)},
+		{"gokeepasslib-v4-chacha20-argon2d-keyfile.kdbx", v4(gokeepasslib.CipherChaCha20, 12), withKeyFile},
+	} {
+		f.db.Credentials = f.credentials
+		f.db.Content.Root.Groups = []gokeepasslib.Group{root()}
+
+		if err := write(filepath.Join("..", f.file), f.db); err != nil {
+			log.Fatalf("%s: %s", f.file, err)
+		}
+	}
+
+	keyFileOnly, err := gokeepasslib.NewKeyDataCredentials(keyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range []struct {
+		file        string
+		credentials *gokeepasslib.DBCredentials
+	}{
+		{"v3-aes-salsa20.kdbx", gokeepasslib.NewPasswordCredentials(password)},
+		{"v4-aes-argon2d.kdbx", gokeepasslib.NewPasswordCredentials(password)},
+		{"v4-aes-aeskdf-keyfile.kdbx", keyFileOnly},
+	} {
+		if err := check(filepath.Join("..", f.file), f.credentials); err != nil {
+			log.Fatalf("%s: %s", f.file, err)
+		}
+	}
+}
+
+func v3() *gokeepasslib.Database {
+	return gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion3())
+}
+
+// v4 returns a KDBX 4 database using Argon2d with parameters which are small enough to keep the tests fast.
+func v4(cipher []byte, ivLength int) *gokeepasslib.Database {
+	db := gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion4())
+
+	h := db.Header.FileHeaders
+	h.CipherID = cipher
+	h.EncryptionIV = make([]byte, ivLength)
+	if _, err := rand.Read(h.EncryptionIV); err != nil {
+		log.Fatal(err)
+	}
+
+	h.KdfParameters.Memory = 64 * 1024
+	h.KdfParameters.Iterations = 2
+	h.KdfParameters.Parallelism = 2
+
+	return db
+}
+
+// root returns the same groups and entries as testRoot in keepass_test.go.
+func root() gokeepasslib.Group {
+	servers := group("00000000000000000000000000000002", "Servers",
+		entry("6f3e2b1c9a8d4e7f8a1b2c3d4e5f6a7b", "web", `CORP\admin`, "p<a>ss&word"),
+		entry("0000000000000000000000000000000b", "sql", "sa", "sqlpass"),
+		entry("0000000000000000000000000000000c", "sql", "sa2", "sqlpass2"))
+
+	r := group("00000000000000000000000000000001", "Root",
+		entry("0000000000000000000000000000000a", "top", "topuser", "toppass"))
+	r.Groups = []gokeepasslib.Group{servers}
+
+	return r
+}
+
+func group(id, name string, entries ...gokeepasslib.Entry) gokeepasslib.Group {
+	g := gokeepasslib.NewGroup()
+	g.UUID = uuid(id)
+	g.Name = name
+	g.Entries = entries
+
+	return g
+}
+
+func entry(id, title, username, password string) gokeepasslib.Entry {
+	e := gokeepasslib.NewEntry()
+	e.UUID = uuid(id)
+	e.Values = []gokeepasslib.ValueData{
+		{Key: "Title", Value: gokeepasslib.V{Content: title}},
+		{Key: "UserName", Value: gokeepasslib.V{Content: username}},
+		{Key: "Password", Value: gokeepasslib.V{Content: password, Protected: w.NewBoolWrapper(true)}},
+	}
+
+	return e
+}
+
+func uuid(s string) gokeepasslib.UUID {
+	var id gokeepasslib.UUID
+
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		log.Fatalf("invalid UUID %s", s)
+	}
+
+	copy(id[:], b)
+
+	return id
+}
+
+// check decodes the database at path and returns an error if the password of Servers/web is wrong.
+func check(path string, credentials *gokeepasslib.DBCredentials) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db := gokeepasslib.NewDatabase()
+	db.Credentials = credentials
+
+	if err := gokeepasslib.NewDecoder(f).Decode(db); err != nil {
+		return err
+	}
+
+	if err := db.UnlockProtectedEntries(); err != nil {
+		return err
+	}
+
+	for _, g := range db.Content.Root.Groups[0].Groups {
+		for _, e := range g.Entries {
+			if g.Name == "Servers" && e.GetTitle() == "web" {
+				if got := e.GetPassword(); got != "p<a>ss&word" {
+					return fmt.Errorf("unexpected password for Servers/web: %s", got)
+				}
+
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("entry Servers/web was not found")
+}
+
+func write(path string, db *gokeepasslib.Database) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// Encode expects protected values to be locked already
+	if err := db.LockProtectedEntries(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := gokeepasslib.NewEncoder(f).Encode(db); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,320 @@
+package keepass
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20"
+)
+
+// testDatabase describes a database written by writeDatabase. Fixed seeds are used so the fixtures are reproducible.
+type testDatabase struct {
+	version  uint16
+	cipher   [16]byte
+	kdf      [16]byte
+	rounds   uint64 // AES-KDF rounds or Argon2 iterations
+	streamID uint32
+	root     *Group
+}
+
+// writeDatabase returns a database encrypted with the given composite key. It writes only what Open reads and is
+// used to generate the fixtures in testdata.
+func writeDatabase(t *testing.T, d testDatabase, compositeKey []byte) []byte {
+	t.Helper()
+
+	seed := bytes.Repeat([]byte{1}, 32)
+	transformSeed := bytes.Repeat([]byte{2}, 32)
+	streamKey := bytes.Repeat([]byte{3}, 32)
+	iv := bytes.Repeat([]byte{4}, 16)
+	startBytes := bytes.Repeat([]byte{5}, 32)
+
+	if d.cipher == cipherChaCha20 {
+		iv = iv[:12]
+	}
+
+	var transformed []byte
+	var err error
+
+	var kdfParams map[string][]byte
+	if d.version == 4 {
+		kdfParams = map[string][]byte{"$UUID": d.kdf[:], "S": transformSeed}
+		if d.kdf == kdfAES {
+			kdfParams["R"] = le64(d.rounds)
+		} else {
+			kdfParams["I"] = le64(d.rounds)
+			kdfParams["M"] = le64(64 * 1024)
+			kdfParams["P"] = le32(1)
+			kdfParams["V"] = le32(0x13)
+		}
+
+		transformed, err = deriveKey(kdfParams, compositeKey)
+	} else {
+		transformed, err = aesKDF(compositeKey, transformSeed, d.rounds)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := sha256.Sum256(append(append([]byte{}, seed...), transformed...))
+
+	var h bytes.Buffer
+	h.Write(le32(signature1))
+	h.Write(le32(signature2))
+	h.Write(le16(1))
+	h.Write(le16(d.version))
+
+	field := func(id byte, value []byte) {
+		h.WriteByte(id)
+		if d.version == 4 {
+			h.Write(le32(uint32(len(value))))
+		} else {
+			h.Write(le16(uint16(len(value))))
+		}
+		h.Write(value)
+	}
+
+	field(headerCipherID, d.cipher[:])
+	field(headerCompression, le32(1))
+	field(headerMasterSeed, seed)
+	field(headerIV, iv)
+
+	if d.version == 4 {
+		field(headerKdfParams, variantDictionary(kdfParams))
+	} else {
+		field(headerTransSeed, transformSeed)
+		field(headerTransRounds, le64(d.rounds))
+		field(headerStreamKey, streamKey)
+		field(headerStartBytes, startBytes)
+		field(headerStreamID, le32(d.streamID))
+	}
+	field(headerEnd, []byte("\r\n\r\n"))
+
+	var payload bytes.Buffer
+	if d.version == 4 {
+		payload.Write([]byte{innerStreamID})
+		payload.Write(le32(4))
+		payload.Write(le32(d.streamID))
+		payload.Write([]byte{innerStreamKey})
+		payload.Write(le32(uint32(len(streamKey))))
+		payload.Write(streamKey)
+		payload.Write([]byte{innerHeaderEnd})
+		payload.Write(le32(0))
+	}
+	payload.Write(writeXML(t, d.root, d.streamID, streamKey))
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(payload.Bytes())
+	gz.Close()
+
+	out := bytes.NewBuffer(append([]byte{}, h.Bytes()...))
+
+	if d.version == 4 {
+		headerHash := sha256.Sum256(h.Bytes())
+		out.Write(headerHash[:])
+
+		hmacBase := sha512.Sum512(append(append(append([]byte{}, seed...), transformed...), 1))
+
+		mac := hmac.New(sha256.New, blockKey(hmacBase[:], ^uint64(0)))
+		mac.Write(h.Bytes())
+		out.Write(mac.Sum(nil))
+
+		encrypted := encrypt(t, d.cipher, key[:], iv, compressed.Bytes())
+		for i, block := range [][]byte{encrypted, {}} {
+			data := append(le32(uint32(len(block))), block...)
+			out.Write(blockHMAC(hmacBase[:], uint64(i), data))
+			out.Write(data)
+		}
+
+		return out.Bytes()
+	}
+
+	var blocks bytes.Buffer
+	blocks.Write(startBytes)
+
+	sum := sha256.Sum256(compressed.Bytes())
+	blocks.Write(le32(0))
+	blocks.Write(sum[:])
+	blocks.Write(le32(uint32(compressed.Len())))
+	blocks.Write(compressed.Bytes())
+
+	blocks.Write(le32(1))
+	blocks.Write(make([]byte, 32))
+	blocks.Write(le32(0))
+
+	out.Write(encrypt(t, d.cipher, key[:], iv, blocks.Bytes()))
+
+	return out.Bytes()
+}
+
+// writeXML returns the XML document of a database. Password fields are protected with the inner random stream.
+func writeXML(t *testing.T, root *Group, streamID uint32, streamKey []byte) []byte {
+	var stream func(b []byte)
+
+	switch streamID {
+	case streamSalsa20:
+		key := sha256.Sum256(streamKey)
+		var all []byte
+		stream = func(b []byte) {
+			// Salsa20 can't be resumed, so the stream is regenerated from the start for each value
+			offset := len(all)
+			all = append(all, b...)
+			out := make([]byte, len(all))
+			salsa20.XORKeyStream(out, all, salsa20Nonce, &key)
+			copy(b, out[offset:])
+		}
+	case streamChaCha20:
+		sum := sha512.Sum512(streamKey)
+		c, err := chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:44])
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = func(b []byte) { c.XORKeyStream(b, b) }
+	default:
+		t.Fatalf("unsupported stream %d", streamID)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>` + "\n<KeePassFile><Meta>" +
+		"<Generator>runrdp tests</Generator></Meta><Root>")
+
+	var group func(g *Group)
+	group = func(g *Group) {
+		fmt.Fprintf(&b, "<Group><UUID>%s</UUID><Name>%s</Name>", uuidBase64(t, g.UUID), escape(g.Name))
+
+		for _, e := range g.Entries {
+			fmt.Fprintf(&b, "<Entry><UUID>%s</UUID>", uuidBase64(t, e.UUID))
+
+			for _, k := range []string{"Title", "UserName", "Password"} {
+				if k == "Password" {
+					v := []byte(e.Fields[k])
+					stream(v)
+					fmt.Fprintf(&b, `<String><Key>%s</Key><Value Protected="True">%s</Value></String>`, k,
+						base64.StdEncoding.EncodeToString(v))
+					continue
+				}
+
+				fmt.Fprintf(&b, "<String><Key>%s</Key><Value>%s</Value></String>", k, escape(e.Fields[k]))
+			}
+
+			b.WriteString("</Entry>")
+		}
+
+		for _, c := range g.Groups {
+			group(c)
+		}
+
+		b.WriteString("</Group>")
+	}
+	group(root)
+
+	b.WriteString("</Root></KeePassFile>")
+
+	return []byte(b.String())
+}
+
+func encrypt(t *testing.T, cipherID [16]byte, key, iv, data []byte) []byte {
+	if cipherID == cipherChaCha20 {
+		c, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := make([]byte, len(data))
+		c.XORKeyStream(out, data)
+
+		return out
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	data = append(append([]byte{}, data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+
+	return out
+}
+
+func variantDictionary(values map[string][]byte) []byte {
+	var b bytes.Buffer
+	b.Write(le16(0x0100))
+
+	for _, k := range []string{"$UUID", "S", "R", "I", "M", "P", "V"} {
+		v, ok := values[k]
+		if !ok {
+			continue
+		}
+
+		switch k {
+		case "R", "I", "M":
+			b.WriteByte(0x05) // UInt64
+		case "P", "V":
+			b.WriteByte(0x04) // UInt32
+		default:
+			b.WriteByte(0x42) // Byte array
+		}
+		b.Write(le32(uint32(len(k))))
+		b.WriteString(k)
+		b.Write(le32(uint32(len(v))))
+		b.Write(v)
+	}
+
+	b.WriteByte(0)
+
+	return b.Bytes()
+}
+
+func uuidBase64(t *testing.T, id string) string {
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
+
+func le16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+
+	return b
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+
+	return b
+}
+
+func le64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+
+	return b
+}
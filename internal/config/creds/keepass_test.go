@@ -0,0 +1,75 @@
+package creds
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config/creds/keepass"
+)
+
+var keePassTestData = filepath.Join("keepass", "testdata")
+
+func TestKeePassStruct(t *testing.T) {
+	var i interface{} = KeePassStruct()
+
+	if _, ok := i.(*KeePass); !ok {
+		t.Errorf("KeePassStruct return value cannot be cast to a KeePass struct")
+	}
+}
+
+func TestKeePass_Validate(t *testing.T) {
+	for _, k := range []*KeePass{
+		{},
+		{File: "db.kdbx"},
+		{File: "db.kdbx", Entry: "web", UUID: "6f3e2b1c9a8d4e7f8a1b2c3d4e5f6a7b"},
+		{File: "db.kdbx", Entry: "web", NoPassword: true},
+	} {
+		if err := k.Validate(); err == nil {
+			t.Errorf("no error returned for invalid fields %+v", k)
+		}
+	}
+
+	if err := (&KeePass{File: "db.kdbx", KeyFile: "db.keyx", NoPassword: true, Entry: "web"}).Validate(); err != nil {
+		t.Errorf("unexpected error returned for valid fields: %s", err)
+	}
+}
+
+func TestKeePass_Retrieve(t *testing.T) {
+	keePassPasswords = make(map[string]string)
+	t.Cleanup(func() { keePassPasswords = make(map[string]string) })
+
+	calls := fakeTerminal(t, true, "wrong", "correct horse battery staple")
+
+	k := &KeePass{File: filepath.Join(keePassTestData, "v3-aes-salsa20.kdbx"), Entry: "Servers/web"}
+	if _, _, err := k.Retrieve(); !errors.Is(err, keepass.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey with the wrong master password: got %v", err)
+	}
+
+	for _, k := range []*KeePass{
+		{File: filepath.Join(keePassTestData, "v3-aes-salsa20.kdbx"), Entry: "Servers/web"},
+		{File: filepath.Join(keePassTestData, "v3-aes-salsa20.kdbx"), UUID: "6f3e2b1c-9a8d-4e7f-8a1b-2c3d4e5f6a7b"},
+		{File: filepath.Join(keePassTestData, "v4-aes-aeskdf-keyfile.kdbx"), Entry: "Servers/web",
+			KeyFile: filepath.Join(keePassTestData, "test.keyx"), NoPassword: true},
+	} {
+		username, password, err := k.Retrieve()
+		if err != nil {
+			t.Errorf("unexpected error retrieving %+v: %s", k, err)
+			continue
+		}
+
+		if username != `CORP\admin` || password != "p<a>ss&word" {
+			t.Errorf("unexpected credentials: want 'CORP\\admin', 'p<a>ss&word': got '%s', '%s'", username, password)
+		}
+	}
+
+	if *calls != 2 {
+		t.Errorf("expected the master password to be prompted for until it was correct: got %d prompts", *calls)
+	}
+
+	k = &KeePass{File: filepath.Join(keePassTestData, "v3-aes-salsa20.kdbx"), Entry: "Servers/missing"}
+	if _, _, err := k.Retrieve(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected an error for a missing entry: got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/runrdp/internal/config/creds/keyring"
+)
+
+// KeyringStruct a struct of type creds.Keyring.
+func KeyringStruct() interface{} {
+	return &Keyring{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (k *Keyring) Validate() error {
+	return nil
+}
+
+// Keyring implements Cred and retrieves a password from the OS secret store: the Secret Service on Linux, Keychain on
+// macOS and Credential Manager on Windows. Passwords are stored with 'runrdp cred set <name>'.
+type Keyring struct {
+	Service string // Service the password is stored under, 'runrdp' if empty
+	Account string // Account the password is stored under, the name of the cred if empty
+
+	name string
+}
+
+// SetName implements Named.
+func (k *Keyring) SetName(name string) {
+	k.name = name
+}
+
+// Retrieve returns an empty username and the stored password.
+func (k *Keyring) Retrieve() (string, string, error) {
+	password, err := keyring.Get(k.service(), k.account())
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", "", fmt.Errorf("no password is stored for %s/%s, store it with 'runrdp cred set'",
+			k.service(), k.account())
+	} else if err != nil {
+		return "", "", err
+	}
+
+	return "", password, nil
+}
+
+// Store stores the password in the OS secret store, replacing any existing password.
+func (k *Keyring) Store(password string) error {
+	return keyring.Set(k.service(), k.account(), password)
+}
+
+// Forget removes the password from the OS secret store. keyring.ErrNotFound is returned if no password is stored.
+func (k *Keyring) Forget() error {
+	return keyring.Delete(k.service(), k.account())
+}
+
+func (k *Keyring) service() string {
+	return stringOrDefault(k.Service, keyring.DefaultService)
+}
+
+func (k *Keyring) account() string {
+	return stringOrDefault(k.Account, k.name)
+}
@@ -0,0 +1,48 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// DefaultService is the name of the service which passwords are stored under in the OS secret store.
+const DefaultService = "runrdp"
+
+// ErrNotFound is returned when there is no password stored for a service and account.
+var ErrNotFound = errors.New("no password stored in the keyring")
+
+// Get returns the password stored for the given service and account in the OS secret store. This is the Secret
+// Service on Linux, Keychain on macOS and Credential Manager on Windows.
+func Get(service, account string) (string, error) {
+	password, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("reading from keyring: %w", err)
+	}
+
+	return password, nil
+}
+
+// Set stores the password for the given service and account in the OS secret store, replacing any existing password.
+func Set(service, account, password string) error {
+	if err := keyring.Set(service, account, password); err != nil {
+		return fmt.Errorf("writing to keyring: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the password for the given service and account from the OS secret store.
+func Delete(service, account string) error {
+	err := keyring.Delete(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		return fmt.Errorf("deleting from keyring: %w", err)
+	}
+
+	return nil
+}
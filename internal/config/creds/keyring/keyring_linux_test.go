@@ -0,0 +1,274 @@
+package keyring
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretsName     = "org.freedesktop.secrets"
+	secretsPath     = "/org/freedesktop/secrets"
+	defaultAlias    = "/org/freedesktop/secrets/aliases/default"
+	itemsPath       = "/org/freedesktop/secrets/collection/login/"
+	sessionPath     = "/org/freedesktop/secrets/session/1"
+	itemInterface   = "org.freedesktop.Secret.Item"
+	noPrompt        = dbus.ObjectPath("/")
+	attributesField = itemInterface + ".Attributes"
+)
+
+// secretService is a stand-in for the D-Bus Secret Service API, providing the methods used by go-keyring. Items are
+// stored in memory in the default collection, which is always unlocked.
+type secretService struct {
+	conn  *dbus.Conn
+	mu    sync.Mutex
+	items map[dbus.ObjectPath]*item
+	next  int
+}
+
+type item struct {
+	service    *secretService
+	path       dbus.ObjectPath
+	attributes map[string]string
+	value      []byte
+}
+
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// OpenSession implements org.freedesktop.Secret.Service.OpenSession for the plain algorithm.
+func (s *secretService) OpenSession(algorithm string, _ dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	if algorithm != "plain" {
+		return dbus.MakeVariant(""), "", dbus.MakeFailedError(fmt.Errorf("algorithm %s is not supported", algorithm))
+	}
+
+	return dbus.MakeVariant(""), sessionPath, nil
+}
+
+// Unlock implements org.freedesktop.Secret.Service.Unlock.
+func (s *secretService) Unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	return objects, noPrompt, nil
+}
+
+// Close implements org.freedesktop.Secret.Session.Close.
+func (s *secretService) Close() *dbus.Error {
+	return nil
+}
+
+// collection implements org.freedesktop.Secret.Collection.
+type collection struct {
+	*secretService
+}
+
+// CreateItem implements org.freedesktop.Secret.Collection.CreateItem.
+func (c collection) CreateItem(properties map[string]dbus.Variant, sec secret,
+	replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	attributes, ok := properties[attributesField].Value().(map[string]string)
+	if !ok {
+		return "", "", dbus.MakeFailedError(errors.New("attributes must be a map of strings"))
+	}
+
+	if existing := c.search(attributes); replace && len(existing) > 0 {
+		c.mu.Lock()
+		c.items[existing[0]].value = sec.Value
+		c.mu.Unlock()
+
+		return existing[0], noPrompt, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.next++
+	i := &item{
+		service:    c.secretService,
+		path:       dbus.ObjectPath(fmt.Sprintf("%s%d", itemsPath, c.next)),
+		attributes: attributes,
+		value:      sec.Value,
+	}
+
+	if err := c.conn.Export(i, i.path, itemInterface); err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+
+	c.items[i.path] = i
+
+	return i.path, noPrompt, nil
+}
+
+// SearchItems implements org.freedesktop.Secret.Collection.SearchItems.
+func (c collection) SearchItems(attributes map[string]string) ([]dbus.ObjectPath, *dbus.Error) {
+	return c.search(attributes), nil
+}
+
+func (s *secretService) search(attributes map[string]string) []dbus.ObjectPath {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]dbus.ObjectPath, 0)
+
+	for path, i := range s.items {
+		match := true
+		for k, v := range attributes {
+			if i.attributes[k] != v {
+				match = false
+			}
+		}
+
+		if match {
+			results = append(results, path)
+		}
+	}
+
+	return results
+}
+
+// GetSecret implements org.freedesktop.Secret.Item.GetSecret.
+func (i *item) GetSecret(session dbus.ObjectPath) (secret, *dbus.Error) {
+	i.service.mu.Lock()
+	defer i.service.mu.Unlock()
+
+	return secret{Session: session, Parameters: []byte{}, Value: i.value, ContentType: "text/plain"}, nil
+}
+
+// Delete implements org.freedesktop.Secret.Item.Delete.
+func (i *item) Delete() (dbus.ObjectPath, *dbus.Error) {
+	i.service.mu.Lock()
+	defer i.service.mu.Unlock()
+
+	delete(i.service.items, i.path)
+
+	if err := i.service.conn.Export(nil, i.path, itemInterface); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+
+	return noPrompt, nil
+}
+
+// startSecretService starts a private session bus with a Secret Service stand-in and points the session bus address
+// at it. The test is skipped if dbus-daemon is not installed.
+func startSecretService(t *testing.T) {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	address := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address", "--address="+address)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting dbus-daemon: %s", err)
+	}
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	// The address is printed when the bus is ready
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading dbus-daemon address: %s", err)
+	}
+
+	old, ok := os.LookupEnv("DBUS_SESSION_BUS_ADDRESS")
+	if err := os.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(line)); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv("DBUS_SESSION_BUS_ADDRESS", old)
+		} else {
+			_ = os.Unsetenv("DBUS_SESSION_BUS_ADDRESS")
+		}
+	})
+
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	if err := conn.Auth(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Hello(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &secretService{conn: conn, items: make(map[dbus.ObjectPath]*item)}
+
+	for _, export := range []struct {
+		v     interface{}
+		path  dbus.ObjectPath
+		iface string
+	}{
+		{s, secretsPath, "org.freedesktop.Secret.Service"},
+		{s, sessionPath, "org.freedesktop.Secret.Session"},
+		{collection{s}, defaultAlias, "org.freedesktop.Secret.Collection"},
+	} {
+		if err := conn.Export(export.v, export.path, export.iface); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reply, err := conn.RequestName(secretsName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("requesting name %s: %v", secretsName, err)
+	}
+}
+
+func TestSecretService(t *testing.T) {
+	startSecretService(t)
+
+	if _, err := Get(DefaultService, "mycred"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before a password is set: got %v", err)
+	}
+
+	for _, password := range []string{"first", "second"} {
+		if err := Set(DefaultService, "mycred", password); err != nil {
+			t.Fatalf("unexpected error setting password: %s", err)
+		}
+
+		got, err := Get(DefaultService, "mycred")
+		if err != nil {
+			t.Fatalf("unexpected error getting password: %s", err)
+		}
+
+		if got != password {
+			t.Errorf("unexpected password: want '%s': got '%s'", password, got)
+		}
+	}
+
+	if _, err := Get("other", "mycred"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a different service: got %v", err)
+	}
+
+	if err := Delete(DefaultService, "mycred"); err != nil {
+		t.Fatalf("unexpected error deleting password: %s", err)
+	}
+
+	if err := Delete(DefaultService, "mycred"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a password which doesn't exist: got %v", err)
+	}
+}
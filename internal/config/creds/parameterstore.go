@@ -0,0 +1,60 @@
+package creds
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+
+	"github.com/danhale-git/runrdp/internal/config/creds/parameterstore"
+)
+
+// ParameterStoreStruct a struct of type creds.ParameterStore.
+func ParameterStoreStruct() interface{} {
+	return &ParameterStore{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (p *ParameterStore) Validate() error {
+	if p.UsernameParam == "" && p.PasswordParam == "" {
+		return fmt.Errorf("either usernameparam or passwordparam must be set")
+	}
+
+	return nil
+}
+
+// ParameterStore implements Cred and retrieves a username and password from AWS SSM Parameter Store. SecureString
+// parameters are decrypted.
+type ParameterStore struct {
+	UsernameParam string
+	PasswordParam string
+	Profile       string
+	Region        string
+
+	svc ssmiface.SSMAPI // Created from Profile and Region if nil
+}
+
+// Retrieve returns the values of the configured parameters or empty strings if the parameter names were not set.
+func (p *ParameterStore) Retrieve() (string, string, error) {
+	if p.svc == nil {
+		p.svc = parameterstore.NewSession(p.Profile, p.Region)
+	}
+
+	username, password := "", ""
+	var err error
+
+	if p.UsernameParam != "" {
+		username, err = parameterstore.Get(p.svc, p.UsernameParam)
+		if err != nil {
+			return "", "", fmt.Errorf("retrieving username: %s", err)
+		}
+	}
+
+	if p.PasswordParam != "" {
+		password, err = parameterstore.Get(p.svc, p.PasswordParam)
+		if err != nil {
+			return "", "", fmt.Errorf("retrieving password: %s", err)
+		}
+	}
+
+	return username, password, nil
+}
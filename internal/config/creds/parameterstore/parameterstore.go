@@ -0,0 +1,51 @@
+package parameterstore
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// NewSession creates and validates a new AWS session. If region is an empty string, .aws/config region settings will be
+// used. A new SSM service is returned.
+func NewSession(profile, region string) ssmiface.SSMAPI {
+	opts := session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Profile:           profile,
+		Config: aws.Config{
+			Region: &region,
+		},
+	}
+
+	sess := session.Must(session.NewSessionWithOptions(opts))
+
+	return ssm.New(sess)
+}
+
+// Get retrieves the value of the parameter with the given name from AWS SSM Parameter Store. SecureString parameters
+// are decrypted.
+func Get(svc ssmiface.SSMAPI, name string) (string, error) {
+	input := &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+
+	result, err := svc.GetParameter(input)
+	if err != nil {
+		if awserror, ok := err.(awserr.Error); ok {
+			return "", fmt.Errorf("getting parameter from parameter store: %s", awserror)
+		}
+
+		return "", fmt.Errorf("getting parameter from parameter store: %s", err)
+	}
+
+	if result.Parameter == nil || result.Parameter.Value == nil {
+		return "", fmt.Errorf("parameter '%s' has no value", name)
+	}
+
+	return *result.Parameter.Value, nil
+}
@@ -0,0 +1,51 @@
+package parameterstore
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+type APIMock struct {
+	ssmiface.SSMAPI
+	Parameters map[string]string // Decrypted parameter values by name
+}
+
+func (s *APIMock) GetParameter(i *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	value, ok := s.Parameters[*i.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "parameter not found", nil)
+	}
+
+	if i.WithDecryption == nil || !*i.WithDecryption {
+		value = "ENCRYPTED"
+	}
+
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: i.Name, Value: &value}}, nil
+}
+
+func TestGet(t *testing.T) {
+	s := APIMock{Parameters: map[string]string{
+		"/rdp/username": "username",
+		"/rdp/password": "password",
+	}}
+
+	testGet(s, "/rdp/username", "username", t)
+	testGet(s, "/rdp/password", "password", t)
+
+	if _, err := Get(&s, "/rdp/missing"); err == nil {
+		t.Errorf("no error returned getting a parameter which doesn't exist")
+	}
+}
+
+func testGet(s APIMock, name, exp string, t *testing.T) {
+	v, err := Get(&s, name)
+	if err != nil {
+		t.Errorf("unexpected error returned getting %s", name)
+	}
+	if v != exp {
+		t.Errorf("unexpected value '%s' returned: expected '%s'", v, exp)
+	}
+}
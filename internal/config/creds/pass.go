@@ -0,0 +1,123 @@
+package creds
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// CommandRunner runs the named program with the given arguments and extra environment variables and returns its
+// stdout.
+type CommandRunner func(name string, args, env []string) ([]byte, error)
+
+// PassStruct a struct of type creds.Pass.
+func PassStruct() interface{} {
+	return &Pass{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (p *Pass) Validate() error {
+	if p.Entry == "" {
+		return fmt.Errorf("entry must be set")
+	}
+
+	return nil
+}
+
+// Pass implements Cred and retrieves a username and password from an entry in pass, the standard unix password
+// manager, or a compatible program such as gopass. The first line of the entry is the password and the username is
+// read from a line beginning with 'username:' or 'login:'.
+type Pass struct {
+	Entry    string // Path of the entry in the store, for example work/rdp/web
+	StoreDir string // Directory of the password store, PASSWORD_STORE_DIR or ~/.password-store if empty
+	Binary   string // Name or path of the program, 'pass' if empty
+
+	run CommandRunner // Runs the program, runCommand if nil
+}
+
+// Retrieve returns the username and password in the entry. The username is an empty string if the entry doesn't have
+// a username line.
+func (p *Pass) Retrieve() (string, string, error) {
+	run := p.run
+	if run == nil {
+		run = runCommand
+	}
+
+	env := make([]string, 0)
+	if p.StoreDir != "" {
+		dir, err := homedir.Expand(p.StoreDir)
+		if err != nil {
+			return "", "", err
+		}
+
+		env = append(env, "PASSWORD_STORE_DIR="+dir)
+	}
+
+	binary := stringOrDefault(p.Binary, "pass")
+
+	out, err := run(binary, []string{"show", p.Entry}, env)
+	if err != nil {
+		return "", "", fmt.Errorf("%s show %s: %w", binary, p.Entry, err)
+	}
+
+	username, password := parsePassEntry(out)
+
+	return username, password, nil
+}
+
+// parsePassEntry returns the username and password in the contents of a pass entry.
+func parsePassEntry(entry []byte) (string, string) {
+	scanner := bufio.NewScanner(bytes.NewReader(entry))
+
+	if !scanner.Scan() {
+		return "", ""
+	}
+
+	password := strings.TrimRight(scanner.Text(), "\r")
+	username := ""
+
+	for scanner.Scan() {
+		key, value, ok := cutString(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "username", "login":
+			if username == "" {
+				username = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return username, password
+}
+
+// runCommand runs a program and returns its stdout. If it fails, the error includes its stderr.
+func runCommand(name string, args, env []string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	out, err := cmd.Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+
+	return out, err
+}
+
+func cutString(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
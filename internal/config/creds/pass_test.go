@@ -0,0 +1,101 @@
+package creds
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakePass returns a CommandRunner which reads entries from an unencrypted store in place of 'pass show'. Entries are
+// files named <entry>.gpg in the directory given by PASSWORD_STORE_DIR.
+func fakePass(t *testing.T) CommandRunner {
+	return func(name string, args, env []string) ([]byte, error) {
+		if name != "pass" || len(args) != 2 || args[0] != "show" {
+			t.Errorf("unexpected command: %s %v", name, args)
+		}
+
+		dir := ""
+		for _, e := range env {
+			if strings.HasPrefix(e, "PASSWORD_STORE_DIR=") {
+				dir = strings.TrimPrefix(e, "PASSWORD_STORE_DIR=")
+			}
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, args[1]+".gpg"))
+		if os.IsNotExist(err) {
+			return nil, errors.New("exit status 1: Error: " + args[1] + " is not in the password store.")
+		}
+
+		return b, err
+	}
+}
+
+func TestPassStruct(t *testing.T) {
+	var i interface{} = PassStruct()
+
+	if _, ok := i.(*Pass); !ok {
+		t.Errorf("PassStruct return value cannot be cast to a Pass struct")
+	}
+}
+
+func TestPass_Retrieve(t *testing.T) {
+	store := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(store, "work"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for entry, content := range map[string]string{
+		"work/web": "p@ss:word\nurl: https://web.example.com\nUsername: administrator\nlogin: ignored\n",
+		"work/sql": "sqlpassword\r\nlogin:  sa \r\n",
+		"work/key": "onlypassword",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(store, entry+".gpg"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		entry, wantUsername, wantPassword string
+	}{
+		{"work/web", "administrator", "p@ss:word"},
+		{"work/sql", "sa", "sqlpassword"},
+		{"work/key", "", "onlypassword"},
+	} {
+		p := &Pass{Entry: tc.entry, StoreDir: store, run: fakePass(t)}
+
+		username, password, err := p.Retrieve()
+		if err != nil {
+			t.Errorf("unexpected error retrieving %s: %s", tc.entry, err)
+			continue
+		}
+
+		if username != tc.wantUsername || password != tc.wantPassword {
+			t.Errorf("unexpected credentials from %s: want '%s', '%s': got '%s', '%s'", tc.entry,
+				tc.wantUsername, tc.wantPassword, username, password)
+		}
+	}
+
+	p := &Pass{Entry: "work/missing", StoreDir: store, run: fakePass(t)}
+	if _, _, err := p.Retrieve(); err == nil || !strings.Contains(err.Error(), "not in the password store") {
+		t.Errorf("expected an error including the output of pass for a missing entry: got %v", err)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	out, err := runCommand("sh", []string{"-c", `printf "%s" "$RUNRDP_TEST"`}, []string{"RUNRDP_TEST=value"})
+	if err != nil {
+		t.Skipf("sh is not available: %s", err)
+	}
+
+	if string(out) != "value" {
+		t.Errorf("unexpected output: want 'value': got '%s'", out)
+	}
+
+	_, err = runCommand("sh", []string{"-c", "echo failed >&2; exit 1"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("expected an error including stderr: got %v", err)
+	}
+}
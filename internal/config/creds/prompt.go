@@ -0,0 +1,121 @@
+package creds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned when a password must be prompted for but stdin is not a terminal.
+var ErrNotTerminal = errors.New("stdin is not a terminal, can't prompt for a password")
+
+// ErrPasswordMismatch is returned when a password is confirmed by re-entry and the two passwords are different.
+var ErrPasswordMismatch = errors.New("passwords do not match")
+
+const defaultPromptMessage = "Password: "
+
+// Replaced in tests
+var (
+	stdinFd                = func() int { return int(os.Stdin.Fd()) }
+	isTerminal             = term.IsTerminal
+	readPassword           = term.ReadPassword
+	promptOutput io.Writer = os.Stderr
+)
+
+// PromptStruct a struct of type creds.Prompt.
+func PromptStruct() interface{} {
+	return &Prompt{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (p *Prompt) Validate() error {
+	return nil
+}
+
+// Prompt implements Cred and reads a password from the terminal with echo disabled. The password is only read once and
+// the same password is returned by later calls to Retrieve.
+type Prompt struct {
+	Message string // Text shown when prompting, 'Password: ' if empty
+	Confirm bool   // Read the password twice and check they match
+
+	password  string
+	retrieved bool
+}
+
+// Retrieve prompts for the password the first time it is called and returns an empty username and the password.
+func (p *Prompt) Retrieve() (string, string, error) {
+	if p.retrieved {
+		return "", p.password, nil
+	}
+
+	message := p.Message
+	if message == "" {
+		message = defaultPromptMessage
+	}
+
+	password, err := PromptPassword(message, p.Confirm)
+	if err != nil {
+		return "", "", err
+	}
+
+	p.password = password
+	p.retrieved = true
+
+	return "", p.password, nil
+}
+
+// PromptPassword writes message to stderr and reads a password from stdin with echo disabled. If confirm is true the
+// password is read a second time and ErrPasswordMismatch is returned if they are different. ErrNotTerminal is returned
+// if stdin is not a terminal.
+func PromptPassword(message string, confirm bool) (string, error) {
+	fd := stdinFd()
+	if !isTerminal(fd) {
+		return "", ErrNotTerminal
+	}
+
+	password, err := readPasswordLine(fd, message)
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return password, nil
+	}
+
+	again, err := readPasswordLine(fd, "Confirm "+lowerFirst(message))
+	if err != nil {
+		return "", err
+	}
+
+	if again != password {
+		return "", ErrPasswordMismatch
+	}
+
+	return password, nil
+}
+
+func readPasswordLine(fd int, message string) (string, error) {
+	fmt.Fprint(promptOutput, message)
+
+	b, err := readPassword(fd)
+
+	// The newline typed by the user is not echoed
+	fmt.Fprintln(promptOutput)
+
+	if err != nil {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+
+	return string(b), nil
+}
+
+func lowerFirst(s string) string {
+	if s == "" || s[0] < 'A' || s[0] > 'Z' {
+		return s
+	}
+
+	return string(s[0]+'a'-'A') + s[1:]
+}
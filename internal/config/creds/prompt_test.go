@@ -0,0 +1,79 @@
+package creds
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+// fakeTerminal replaces the terminal functions used by PromptPassword, returning each of passwords in turn.
+func fakeTerminal(t *testing.T, terminal bool, passwords ...string) *int {
+	calls := 0
+
+	fd, terminalFunc, readFunc, output := stdinFd, isTerminal, readPassword, promptOutput
+	t.Cleanup(func() {
+		stdinFd, isTerminal, readPassword, promptOutput = fd, terminalFunc, readFunc, output
+	})
+
+	stdinFd = func() int { return 0 }
+	isTerminal = func(int) bool { return terminal }
+	readPassword = func(int) ([]byte, error) {
+		if calls >= len(passwords) {
+			return nil, errors.New("no more input")
+		}
+		calls++
+		return []byte(passwords[calls-1]), nil
+	}
+	promptOutput = ioutil.Discard
+
+	return &calls
+}
+
+func TestPromptStruct(t *testing.T) {
+	var i interface{} = PromptStruct()
+
+	if _, ok := i.(*Prompt); !ok {
+		t.Errorf("PromptStruct return value cannot be cast to a Prompt struct")
+	}
+}
+
+func TestPrompt_Retrieve(t *testing.T) {
+	calls := fakeTerminal(t, true, "secret", "other")
+
+	p := &Prompt{}
+	for i := 0; i < 2; i++ {
+		username, password, err := p.Retrieve()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if username != "" || password != "secret" {
+			t.Errorf("unexpected credentials: want '', 'secret': got '%s', '%s'", username, password)
+		}
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected the password to be read once and cached: read %d times", *calls)
+	}
+}
+
+func TestPromptPassword(t *testing.T) {
+	fakeTerminal(t, true, "secret", "secret")
+	if password, err := PromptPassword("Password: ", true); err != nil || password != "secret" {
+		t.Errorf("unexpected result confirming matching passwords: '%s', %v", password, err)
+	}
+
+	fakeTerminal(t, true, "secret", "typo")
+	if _, err := PromptPassword("Password: ", true); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("expected ErrPasswordMismatch: got %v", err)
+	}
+
+	calls := fakeTerminal(t, false, "secret")
+	if _, err := PromptPassword("Password: ", false); !errors.Is(err, ErrNotTerminal) {
+		t.Errorf("expected ErrNotTerminal: got %v", err)
+	}
+
+	if *calls != 0 {
+		t.Errorf("password was read when stdin is not a terminal")
+	}
+}
@@ -0,0 +1,187 @@
+package secretfile
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v2"
+)
+
+// Kind is the type of encryption used by a secret file.
+type Kind int
+
+const (
+	// Plain files are not encrypted.
+	Plain Kind = iota
+	// Age files are encrypted with age (https://age-encryption.org), in binary or ASCII armored form.
+	Age
+	// Sops files are YAML or JSON files with values encrypted by sops (https://github.com/getsops/sops).
+	Sops
+)
+
+func (k Kind) String() string {
+	return [...]string{"plain", "age", "sops"}[k]
+}
+
+const ageHeader = "age-encryption.org/v1\n"
+
+// Detect returns the kind of the given file contents.
+func Detect(data []byte) Kind {
+	if isAge(data) {
+		return Age
+	}
+
+	if doc, err := Parse(data); err == nil {
+		if m, ok := doc.(map[interface{}]interface{}); ok {
+			if _, ok := m["sops"].(map[interface{}]interface{}); ok {
+				return Sops
+			}
+		}
+	}
+
+	return Plain
+}
+
+func isAge(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageHeader)) ||
+		bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+}
+
+// dearmor returns the binary form of an age file.
+func dearmor(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, []byte(ageHeader)) {
+		return data, nil
+	}
+
+	return ioutil.ReadAll(armor.NewReader(bytes.NewReader(bytes.TrimSpace(data))))
+}
+
+// IsPassphraseEncrypted returns true if the given age file is encrypted with a passphrase rather than to recipients.
+func IsPassphraseEncrypted(data []byte) bool {
+	binary, err := dearmor(data)
+	if err != nil {
+		return false
+	}
+
+	// The header ends with a MAC line beginning with '---' and is followed by the binary payload
+	header := binary
+	if i := bytes.Index(binary, []byte("\n--- ")); i >= 0 {
+		header = binary[:i]
+	}
+
+	return bytes.Contains(header, []byte("\n-> scrypt "))
+}
+
+// DecryptAge decrypts an age file with the given identities.
+func DecryptAge(data []byte, identities ...age.Identity) ([]byte, error) {
+	binary, err := dearmor(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading armored age file: %w", err)
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(binary), identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+// DefaultIdentityFile returns the path of the age identity file used by sops, which is SOPS_AGE_KEY_FILE or
+// sops/age/keys.txt in the user config directory.
+func DefaultIdentityFile() (string, error) {
+	if path := os.Getenv("SOPS_AGE_KEY_FILE"); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "sops", "age", "keys.txt"), nil
+}
+
+// ReadIdentities returns the age identities in the file at path.
+func ReadIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading age identities from %s: %w", path, err)
+	}
+
+	return identities, nil
+}
+
+// Parse parses YAML or JSON file contents.
+func Parse(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Lookup returns the value in a parsed document at the given key, which is a dot separated path through nested
+// objects, for example servers.web. The document is returned if key is empty.
+func Lookup(doc interface{}, key string) (interface{}, error) {
+	if key == "" {
+		return doc, nil
+	}
+
+	v := doc
+	for _, part := range strings.Split(key, ".") {
+		m, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key '%s' was not found: '%s' is not in an object", key, part)
+		}
+
+		if v, ok = m[part]; !ok {
+			return nil, fmt.Errorf("key '%s' was not found", key)
+		}
+	}
+
+	return v, nil
+}
+
+// String returns a scalar value from a parsed document as a string. An empty string is returned for nil values.
+func String(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case map[interface{}]interface{}, []interface{}:
+		return "", fmt.Errorf("expected a string value: got an object or list")
+	}
+
+	return fmt.Sprint(v), nil
+}
+
+// CheckPermissions returns an error if a plaintext file can be accessed by users other than its owner. Permissions
+// are not checked on Windows, where they are not represented by the file mode.
+func CheckPermissions(info os.FileInfo) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("%s is not encrypted and has permissions %#o, it must only be accessible by its owner "+
+			"(chmod 600)", info.Name(), perm)
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package secretfile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const testDocument = `servers:
+  web:
+    username: webuser
+    password: 1234
+`
+
+func encrypt(t *testing.T, armored bool, recipients ...age.Recipient) []byte {
+	var b bytes.Buffer
+
+	var dst io.Writer = &b
+	var a io.WriteCloser
+	if armored {
+		a = armor.NewWriter(&b)
+		dst = a
+	}
+
+	w, err := age.Encrypt(dst, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte(testDocument)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if a != nil {
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return b.Bytes()
+}
+
+func TestDecryptAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scrypt, err := age.NewScryptRecipient("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scrypt.SetWorkFactor(10)
+
+	passphrase, err := age.NewScryptIdentity("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		data       []byte
+		identity   age.Identity
+		passphrase bool
+	}{
+		{"binary", encrypt(t, false, identity.Recipient()), identity, false},
+		{"armored", encrypt(t, true, identity.Recipient()), identity, false},
+		{"passphrase", encrypt(t, true, scrypt), passphrase, true},
+	} {
+		if kind := Detect(tc.data); kind != Age {
+			t.Errorf("%s: expected kind age: got %s", tc.name, kind)
+		}
+
+		if IsPassphraseEncrypted(tc.data) != tc.passphrase {
+			t.Errorf("%s: expected IsPassphraseEncrypted to return %t", tc.name, tc.passphrase)
+		}
+
+		plain, err := DecryptAge(tc.data, tc.identity)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+			continue
+		}
+
+		if string(plain) != testDocument {
+			t.Errorf("%s: unexpected plaintext: %s", tc.name, plain)
+		}
+	}
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptAge(encrypt(t, false, identity.Recipient()), other); err == nil {
+		t.Errorf("expected error decrypting with the wrong identity")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for data, want := range map[string]Kind{
+		testDocument:                 Plain,
+		`{"web": {"password": "p"}}`: Plain,
+		"web:\n  password: ENC[AES256_GCM]\nsops:\n  version: 3.7.1\n": Sops,
+		`{"web": {}, "sops": {"mac": "ENC[]"}}`:                        Sops,
+		"sops: true\n":                                                 Plain,
+	} {
+		if got := Detect([]byte(data)); got != want {
+			t.Errorf("expected kind %s for %q: got %s", want, data, got)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc, err := Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Lookup(doc, "servers.web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password, err := String(v.(map[interface{}]interface{})["password"])
+	if err != nil || password != "1234" {
+		t.Errorf("expected password '1234': got '%s', %v", password, err)
+	}
+
+	for _, key := range []string{"servers.sql", "servers.web.username.x", "missing"} {
+		if _, err := Lookup(doc, key); err == nil {
+			t.Errorf("expected error looking up '%s'", key)
+		}
+	}
+
+	if _, err := String(v); err == nil {
+		t.Errorf("expected error converting an object to a string")
+	}
+}
+
+func TestReadIdentities(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	if err := ioutil.WriteFile(path, []byte("# created: today\n"+identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	identities, err := ReadIdentities(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(identities) != 1 {
+		t.Errorf("expected 1 identity: got %d", len(identities))
+	}
+
+	if err := os.Setenv("SOPS_AGE_KEY_FILE", path); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("SOPS_AGE_KEY_FILE")
+
+	if p, err := DefaultIdentityFile(); err != nil || p != path {
+		t.Errorf("expected the default identity file to be SOPS_AGE_KEY_FILE: got '%s', %v", p, err)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+
+	dir := t.TempDir()
+
+	for mode, valid := range map[os.FileMode]bool{0600: true, 0400: true, 0640: false, 0604: false} {
+		path := filepath.Join(dir, mode.String())
+		if err := ioutil.WriteFile(path, []byte(testDocument), mode); err != nil {
+			t.Fatal(err)
+		}
+
+		// The mode passed to WriteFile is modified by the umask
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := CheckPermissions(info); (err == nil) != valid {
+			t.Errorf("unexpected result checking mode %#o: %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package creds
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/tss-sdk-go/server"
+
+	"github.com/danhale-git/runrdp/internal/config/creds/tss"
+)
+
+// SecretServerStruct a struct of type creds.SecretServer.
+func SecretServerStruct() interface{} {
+	return &SecretServer{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (s *SecretServer) Validate() error {
+	if (s.Server == "") == (s.Tenant == "") {
+		return fmt.Errorf("either server or tenant must be set")
+	}
+
+	if (s.SecretID == 0) == (s.SecretName == "") {
+		return fmt.Errorf("either secretid or secretname must be set")
+	}
+
+	if s.FolderID != 0 && s.SecretName == "" {
+		return fmt.Errorf("folderid can only be set with secretname")
+	}
+
+	return nil
+}
+
+// SecretServer implements Cred and retrieves a username and password from a secret in Delinea (Thycotic) Secret
+// Server. The secret is found by ID or by searching for its name.
+type SecretServer struct {
+	Server string // URL of the Secret Server, for example https://example.com/SecretServer
+	Tenant string // Secret Server Cloud tenant, used instead of Server
+	TLD    string // Top level domain of the Secret Server Cloud tenant, 'com' if empty
+
+	APIUser     string // User to authenticate with, TSS_USERNAME if empty
+	APIPassword string `secret:"true"` // Password of APIUser, TSS_PASSWORD if empty
+	APIDomain   string // Domain of APIUser
+
+	SecretID   int
+	SecretName string
+	FolderID   int // Only search for SecretName in this folder
+
+	UsernameField string // Name or slug of the username field of the secret template, 'username' if empty
+	PasswordField string // Name or slug of the password field of the secret template, 'password' if empty
+	DomainField   string // Name or slug of a field containing the domain of the username, 'domain' if empty
+	UPN           bool   // Qualify the username as username@domain instead of DOMAIN\username
+}
+
+// Retrieve returns the values of the username and password fields of the configured secret.
+func (s *SecretServer) Retrieve() (string, string, error) {
+	svc, err := tss.NewServer(s.Server, s.Tenant, s.TLD,
+		stringOrEnv(s.APIUser, "TSS_USERNAME"), stringOrEnv(s.APIPassword, "TSS_PASSWORD"), s.APIDomain)
+	if err != nil {
+		return "", "", err
+	}
+
+	var secret *server.Secret
+	if s.SecretID != 0 {
+		secret, err = svc.Secret(s.SecretID)
+	} else {
+		secret, err = tss.FindSecret(svc, s.FolderID, s.SecretName)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("retrieving secret: %w", err)
+	}
+
+	return tss.Credentials(secret,
+		stringOrDefault(s.UsernameField, "username"),
+		stringOrDefault(s.PasswordField, "password"),
+		stringOrDefault(s.DomainField, "domain"),
+		s.UPN,
+	)
+}
@@ -3,6 +3,8 @@ package creds
 import (
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+
 	"github.com/danhale-git/runrdp/internal/config/creds/secretsmanager"
 )
 
@@ -13,36 +15,75 @@ func SecretsManagerStruct() interface{} {
 
 // Validate returns an error if a config field is invalid.
 func (s *SecretsManager) Validate() error {
-	if s.UsernameID == "" && s.PasswordID == "" {
-		return fmt.Errorf("either usernameid or passwordid must be set")
+	if s.UsernameID == "" && s.PasswordID == "" && s.SecretID == "" {
+		return fmt.Errorf("either usernameid, passwordid or secretid must be set")
+	}
+
+	if s.SecretID == "" && (s.UsernameKey != "" || s.PasswordKey != "") {
+		return fmt.Errorf("usernamekey and passwordkey can only be set with secretid")
+	}
+
+	if s.SecretID == "" && (s.VersionStage != "" || s.VersionID != "") {
+		return fmt.Errorf("versionstage and versionid can only be set with secretid")
 	}
 
 	return nil
 }
 
-// SecretsManager implements Cred and retrieves a username and password from AWS Secrets Manager.
+// SecretsManager implements Cred and retrieves a username and password from AWS Secrets Manager, from the keys of a
+// single secret which is a JSON object and/or from separate secrets. Separate secrets take precedence.
 type SecretsManager struct {
 	UsernameID string
 	PasswordID string
-	Profile    string
-	Region     string
+
+	SecretID    string // A JSON secret containing both the username and password
+	UsernameKey string // Key of the username in the SecretID secret, 'username' if empty
+	PasswordKey string // Key of the password in the SecretID secret, 'password' if empty
+
+	VersionStage string // Staging label of the SecretID version to retrieve, for example AWSPREVIOUS
+	VersionID    string // ID of the SecretID version to retrieve
+
+	Profile string
+	Region  string
+
+	svc secretsmanageriface.SecretsManagerAPI // Created from Profile and Region if nil
 }
 
-// Retrieve returns the values for the configured Secrets Manager key or empty strings if the keys were not set.
+// Retrieve returns the values for the configured Secrets Manager keys or empty strings if the keys were not set.
 func (s *SecretsManager) Retrieve() (string, string, error) {
-	svc := secretsmanager.NewSession(s.Profile, s.Region)
+	if s.svc == nil {
+		s.svc = secretsmanager.NewSession(s.Profile, s.Region)
+	}
 
 	username, password := "", ""
+
+	if s.SecretID != "" {
+		secret, err := secretsmanager.GetVersion(s.svc, s.SecretID, s.VersionStage, s.VersionID)
+		if err != nil {
+			return "", "", fmt.Errorf("retrieving secret: %s", err)
+		}
+
+		username, password, err = secretsmanager.Keys(secret,
+			stringOrDefault(s.UsernameKey, "username"), stringOrDefault(s.PasswordKey, "password"))
+		if err != nil {
+			return "", "", fmt.Errorf("secret %s: %s", s.SecretID, err)
+		}
+	}
+
 	var err error
 
-	username, err = secretsmanager.Get(svc, s.UsernameID)
-	if err != nil {
-		return "", "", fmt.Errorf("retrieving username: %s", err)
+	if s.UsernameID != "" {
+		username, err = secretsmanager.Get(s.svc, s.UsernameID)
+		if err != nil {
+			return "", "", fmt.Errorf("retrieving username: %s", err)
+		}
 	}
 
-	password, err = secretsmanager.Get(svc, s.PasswordID)
-	if err != nil {
-		return "", "", fmt.Errorf("retrieving password: %s", err)
+	if s.PasswordID != "" {
+		password, err = secretsmanager.Get(s.svc, s.PasswordID)
+		if err != nil {
+			return "", "", fmt.Errorf("retrieving password: %s", err)
+		}
 	}
 
 	return username, password, nil
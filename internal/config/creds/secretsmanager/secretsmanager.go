@@ -5,6 +5,7 @@ package secretsmanager
 // https://docs.aws.amazon.com/sdk-for-go/v1/developer-guide/setting-up.html
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
@@ -32,12 +33,27 @@ func NewSession(profile, region string) secretsmanageriface.SecretsManagerAPI {
 	return secretsmanager.New(sess)
 }
 
-// Get retrieves the secret with the given key from AWS Secrets Manager.
+// Get retrieves the current version of the secret with the given key from AWS Secrets Manager.
 func Get(svc secretsmanageriface.SecretsManagerAPI, secretKey string) (string, error) {
+	return GetVersion(svc, secretKey, "", "")
+}
+
+// GetVersion retrieves the secret with the given key from AWS Secrets Manager. If versionStage or versionID are not
+// empty strings, the version of the secret with that staging label or ID is retrieved. The value of a binary secret
+// is returned as a string.
+func GetVersion(svc secretsmanageriface.SecretsManagerAPI, secretKey, versionStage, versionID string) (string, error) {
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(secretKey),
 	}
 
+	if versionStage != "" {
+		input.VersionStage = aws.String(versionStage)
+	}
+
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+
 	result, err := svc.GetSecretValue(input)
 	if err != nil {
 		if awserror, ok := err.(awserr.Error); ok {
@@ -47,12 +63,52 @@ func Get(svc secretsmanageriface.SecretsManagerAPI, secretKey string) (string, e
 		return "", fmt.Errorf("getting secret from secrets manager: %s", err)
 	}
 
-	var secretString, _ string
-	if result.SecretString != nil {
-		secretString = *result.SecretString
-	} else {
-		return "", fmt.Errorf("secret type of '%s' is binary, not string", secretKey)
+	switch {
+	case result.SecretString != nil:
+		return *result.SecretString, nil
+	case result.SecretBinary != nil:
+		// The SDK decodes the base64 encoded value returned by the API
+		return string(result.SecretBinary), nil
+	}
+
+	return "", fmt.Errorf("secret '%s' has no value", secretKey)
+}
+
+// Keys returns the values of usernameKey and passwordKey in a secret which is a JSON object. The username is an empty
+// string if the secret doesn't have usernameKey.
+func Keys(secret, usernameKey, passwordKey string) (string, string, error) {
+	values := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", "", fmt.Errorf("secret is not a JSON object: %s", err)
+	}
+
+	username, err := stringKey(values, usernameKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, ok := values[passwordKey]; !ok {
+		return "", "", fmt.Errorf("secret has no key '%s'", passwordKey)
+	}
+
+	password, err := stringKey(values, passwordKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+func stringKey(values map[string]interface{}, key string) (string, error) {
+	raw, ok := values[key]
+	if !ok {
+		return "", nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("value of key '%s' in secret is not a string", key)
 	}
 
-	return secretString, nil
+	return s, nil
 }
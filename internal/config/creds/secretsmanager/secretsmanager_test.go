@@ -10,11 +10,24 @@ import (
 
 type APIMock struct {
 	secretsmanageriface.SecretsManagerAPI
-	SecretValues map[string]string
+	SecretValues map[string]string // Secret strings by ID followed by the version stage and ID, separated by ':'
+	BinaryValues map[string][]byte
 }
 
 func (s *APIMock) GetSecretValue(i *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
-	secret := s.SecretValues[*i.SecretId]
+	id := *i.SecretId
+	if i.VersionStage != nil {
+		id += ":" + *i.VersionStage
+	}
+	if i.VersionId != nil {
+		id += ":" + *i.VersionId
+	}
+
+	if binary, ok := s.BinaryValues[id]; ok {
+		return &secretsmanager.GetSecretValueOutput{SecretBinary: binary}, nil
+	}
+
+	secret := s.SecretValues[id]
 	return &secretsmanager.GetSecretValueOutput{SecretString: &secret}, nil
 }
 
@@ -37,3 +50,57 @@ func testGet(s APIMock, id, exp string, t *testing.T) {
 		t.Errorf("unexpected value '%s' returned: expected '%s'", u, exp)
 	}
 }
+
+func TestGetVersion(t *testing.T) {
+	s := APIMock{
+		SecretValues: map[string]string{
+			"testPassword:AWSPREVIOUS":   "oldpassword",
+			"testPassword:AWSCURRENT:v2": "password",
+		},
+		BinaryValues: map[string][]byte{
+			"testBinary": []byte("binarypassword"),
+		},
+	}
+
+	for _, tc := range []struct {
+		stage, id, want string
+	}{
+		{"AWSPREVIOUS", "", "oldpassword"},
+		{"AWSCURRENT", "v2", "password"},
+	} {
+		got, err := GetVersion(&s, "testPassword", tc.stage, tc.id)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		if got != tc.want {
+			t.Errorf("unexpected value '%s' returned for version %s %s: expected '%s'", got, tc.stage, tc.id, tc.want)
+		}
+	}
+
+	testGet(s, "testBinary", "binarypassword", t)
+}
+
+func TestKeys(t *testing.T) {
+	username, password, err := Keys(`{"user":"admin","pass":"secret","engine":"sqlserver"}`, "user", "pass")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if username != "admin" || password != "secret" {
+		t.Errorf("unexpected values: want 'admin', 'secret': got '%s', '%s'", username, password)
+	}
+
+	if username, _, err = Keys(`{"password":"secret"}`, "username", "password"); err != nil || username != "" {
+		t.Errorf("expected an empty username and no error when the username key is missing: got '%s', %v",
+			username, err)
+	}
+
+	for _, secret := range []string{
+		`{"username":"admin"}`,
+		`{"username":"admin","password":1234}`,
+		`not json`,
+	} {
+		if _, _, err := Keys(secret, "username", "password"); err == nil {
+			t.Errorf("no error returned for secret %s", secret)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tss
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/tss-sdk-go/server"
+)
+
+// NewServer returns a client for the Secret Server at serverURL, or the Secret Server Cloud tenant if serverURL is
+// empty. The client authenticates as the given user. domain is the domain of the user and may be empty.
+func NewServer(serverURL, tenant, tld, username, password, domain string) (*server.Server, error) {
+	return server.New(server.Configuration{
+		Credentials: server.UserCredential{
+			Username: username,
+			Password: password,
+		},
+		ServerURL: strings.TrimRight(serverURL, "/"),
+		Tenant:    tenant,
+		TLD:       tld,
+		Domain:    domain,
+	})
+}
+
+// FindSecret returns the secret with the given name, ignoring case. If folderID is not 0, only secrets in that folder
+// are matched. An error is returned if no secrets or more than one secret match.
+func FindSecret(s *server.Server, folderID int, name string) (*server.Secret, error) {
+	ids := make([]int, 0)
+
+	id, err := s.SecretNameToID(name)
+
+	var multiple server.MultipleSecretsFoundError
+	switch {
+	case errors.As(err, &multiple):
+		ids = multiple.IDs
+	case err != nil:
+		return nil, fmt.Errorf("searching for secret '%s': %w", name, err)
+	default:
+		ids = append(ids, id)
+	}
+
+	// The search matches partial names so each secret is checked
+	matches := make([]*server.Secret, 0)
+	for _, id := range ids {
+		secret, err := s.Secret(id)
+		if err != nil {
+			return nil, fmt.Errorf("getting secret %d: %w", id, err)
+		}
+
+		if strings.EqualFold(secret.Name, name) && (folderID == 0 || secret.FolderID == folderID) {
+			matches = append(matches, secret)
+		}
+	}
+
+	inFolder := ""
+	if folderID != 0 {
+		inFolder = fmt.Sprintf(" in folder %d", folderID)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no secret named '%s' found%s", name, inFolder)
+	case 1:
+		return matches[0], nil
+	}
+
+	matchedIDs := make([]string, len(matches))
+	for i, m := range matches {
+		matchedIDs[i] = fmt.Sprint(m.ID)
+	}
+
+	return nil, fmt.Errorf("%d secrets named '%s' found%s, set the secret ID to one of: %s", len(matches), name,
+		inFolder, strings.Join(matchedIDs, ", "))
+}
+
+// Credentials returns the values of the username and password fields of secret. Fields may be given by name or slug.
+// If domainField is not empty and the secret has a non-empty value for it, the username is qualified with the domain
+// as DOMAIN\username, or username@domain if upn is true. Usernames which are already qualified are not changed.
+func Credentials(secret *server.Secret, usernameField, passwordField, domainField string,
+	upn bool) (string, string, error) {
+	username, ok := secret.Field(usernameField)
+	if !ok {
+		return "", "", fmt.Errorf("secret %d has no field '%s'", secret.ID, usernameField)
+	}
+
+	password, ok := secret.Field(passwordField)
+	if !ok {
+		return "", "", fmt.Errorf("secret %d has no field '%s'", secret.ID, passwordField)
+	}
+
+	domain := ""
+	if domainField != "" {
+		domain, _ = secret.Field(domainField)
+	}
+
+	if domain == "" || strings.ContainsAny(username, `\@`) {
+		return username, password, nil
+	}
+
+	if upn {
+		return fmt.Sprintf("%s@%s", username, domain), password, nil
+	}
+
+	return fmt.Sprintf(`%s\%s`, domain, username), password, nil
+}
@@ -0,0 +1,173 @@
+package tss
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/tss-sdk-go/server"
+)
+
+const testToken = "testtoken"
+
+// testSecrets are the secrets returned by the stand-in server by ID.
+var testSecrets = map[int]*server.Secret{
+	1: testSecret(1, 10, "web", "administrator", "webpassword", "CORP"),
+	2: testSecret(2, 10, "web-old", "administrator", "oldpassword", ""),
+	3: testSecret(3, 20, "web", `OTHER\admin`, "otherpassword", "OTHER"),
+	4: testSecret(4, 30, "sql", "sa", "sqlpassword", ""),
+	5: testSecret(5, 30, "sql", "sa", "sqlpassword2", ""),
+}
+
+func testSecret(id, folderID int, name, username, password, domain string) *server.Secret {
+	return &server.Secret{ID: id, FolderID: folderID, Name: name, Fields: []server.SecretField{
+		{FieldName: "Username", Slug: "username", ItemValue: username},
+		{FieldName: "Password", Slug: "password", ItemValue: password, IsPassword: true},
+		{FieldName: "Domain", Slug: "domain", ItemValue: domain},
+	}}
+}
+
+// newServer returns a stand-in for the Secret Server REST API and a client for it.
+func newServer(t *testing.T) *server.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("username") != "apiuser" ||
+				r.PostForm.Get("password") != "apipassword" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			_, _ = fmt.Fprintf(w, `{"access_token":"%s","token_type":"bearer"}`, testToken)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/secrets/")
+
+		if path == "" {
+			// Search by name matches partial names and ignores case
+			type record struct {
+				ID   int
+				Name string
+			}
+
+			records := make([]record, 0)
+			for id := 1; id <= len(testSecrets); id++ {
+				search := strings.ToLower(r.URL.Query().Get("filter.searchText"))
+				if strings.Contains(strings.ToLower(testSecrets[id].Name), search) {
+					records = append(records, record{ID: id, Name: testSecrets[id].Name})
+				}
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"Records": records})
+			return
+		}
+
+		id, err := strconv.Atoi(path)
+		if err != nil || testSecrets[id] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(testSecrets[id])
+	}))
+
+	t.Cleanup(s.Close)
+
+	svc, err := NewServer(s.URL+"/", "", "", "apiuser", "apipassword", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return svc
+}
+
+func TestFindSecret(t *testing.T) {
+	svc := newServer(t)
+
+	for _, tc := range []struct {
+		folderID int
+		name     string
+		wantID   int
+	}{
+		{10, "web", 1},
+		{20, "WEB", 3},
+		{0, "web-old", 2},
+	} {
+		secret, err := FindSecret(svc, tc.folderID, tc.name)
+		if err != nil {
+			t.Errorf("unexpected error finding '%s' in folder %d: %s", tc.name, tc.folderID, err)
+			continue
+		}
+
+		if secret.ID != tc.wantID {
+			t.Errorf("unexpected secret found for '%s' in folder %d: want %d: got %d", tc.name, tc.folderID,
+				tc.wantID, secret.ID)
+		}
+	}
+
+	for _, tc := range []struct {
+		folderID int
+		name     string
+	}{
+		{0, "web"},     // Two secrets in different folders
+		{30, "sql"},    // Two secrets in the same folder
+		{40, "web"},    // No secret in the folder
+		{0, "missing"}, // No secret with the name
+	} {
+		if secret, err := FindSecret(svc, tc.folderID, tc.name); err == nil {
+			t.Errorf("expected error finding '%s' in folder %d: got secret %d", tc.name, tc.folderID, secret.ID)
+		}
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		secret       *server.Secret
+		domainField  string
+		upn          bool
+		wantUsername string
+	}{
+		{testSecrets[1], "domain", false, `CORP\administrator`},
+		{testSecrets[1], "Domain", true, "administrator@CORP"},
+		{testSecrets[1], "", false, "administrator"},
+		{testSecrets[2], "domain", false, "administrator"},
+		{testSecrets[3], "domain", false, `OTHER\admin`},
+	} {
+		username, password, err := Credentials(tc.secret, "username", "Password", tc.domainField, tc.upn)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			continue
+		}
+
+		if username != tc.wantUsername || password != tc.secret.Fields[1].ItemValue {
+			t.Errorf("unexpected credentials from secret %d: want '%s': got '%s', '%s'", tc.secret.ID,
+				tc.wantUsername, username, password)
+		}
+	}
+
+	if _, _, err := Credentials(testSecrets[1], "login", "password", "", false); err == nil {
+		t.Errorf("no error returned when the secret has no username field")
+	}
+}
+
+func TestServer_Secret(t *testing.T) {
+	svc := newServer(t)
+
+	secret, err := svc.Secret(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if password, _ := secret.Field("password"); password != "sqlpassword" {
+		t.Errorf("unexpected password: %s", password)
+	}
+}
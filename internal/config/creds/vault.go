@@ -0,0 +1,142 @@
+package creds
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danhale-git/runrdp/internal/config/creds/vault"
+)
+
+// VaultStruct a struct of type creds.Vault.
+func VaultStruct() interface{} {
+	return &Vault{}
+}
+
+// Validate returns an error if a config field is invalid.
+func (v *Vault) Validate() error {
+	if v.Path == "" {
+		return fmt.Errorf("path must be set")
+	}
+
+	if v.KVVersion != 0 && v.KVVersion != 1 && v.KVVersion != 2 {
+		return fmt.Errorf("kvversion must be 1 or 2")
+	}
+
+	if (v.RoleID == "") != (v.SecretID == "") {
+		return fmt.Errorf("roleid and secretid must be set together")
+	}
+
+	if v.Token != "" && v.RoleID != "" {
+		return fmt.Errorf("token and roleid can't be set together")
+	}
+
+	return nil
+}
+
+// Vault implements Cred and retrieves a username and password from a secret in a HashiCorp Vault KV secrets engine.
+//
+// The Vault token is the first of: the Token field, a token from logging in with AppRole using RoleID and SecretID,
+// the VAULT_TOKEN environment variable and the contents of ~/.vault-token.
+type Vault struct {
+	Server      string // Address of the Vault server, VAULT_ADDR if empty
+	Mount       string // Path the KV secrets engine is mounted at, 'secret' if empty
+	Path        string // Path of the secret within the mount
+	KVVersion   int    // Version of the KV secrets engine, 2 if not set
+	UsernameKey string // Key of the username in the secret, 'username' if empty
+	PasswordKey string // Key of the password in the secret, 'password' if empty
+	Namespace   string // Vault Enterprise namespace, VAULT_NAMESPACE if empty
+	CACert      string // Path to a PEM encoded CA certificate, VAULT_CACERT if empty
+
+	Token        string `secret:"true"`
+	RoleID       string
+	SecretID     string `secret:"true"`
+	AppRoleMount string // Path the AppRole auth method is mounted at, 'approle' if empty
+}
+
+// Retrieve returns the values of the username and password keys of the configured secret. The username is an empty
+// string if the secret doesn't have the username key.
+func (v *Vault) Retrieve() (string, string, error) {
+	client, err := vault.NewClient(
+		stringOrEnv(v.Server, "VAULT_ADDR"),
+		stringOrEnv(v.CACert, "VAULT_CACERT"),
+		stringOrEnv(v.Namespace, "VAULT_NAMESPACE"),
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	if client.Address == "" {
+		return "", "", fmt.Errorf("server is not set and VAULT_ADDR is not set")
+	}
+
+	switch {
+	case v.Token != "":
+		client.Token = v.Token
+	case v.RoleID != "":
+		if err := client.LoginAppRole(stringOrDefault(v.AppRoleMount, "approle"), v.RoleID, v.SecretID); err != nil {
+			return "", "", err
+		}
+	default:
+		if client.Token, err = vault.TokenFromEnvironment(); err != nil {
+			return "", "", fmt.Errorf("reading vault token: %w", err)
+		}
+	}
+
+	version := v.KVVersion
+	if version == 0 {
+		version = 2
+	}
+
+	data, err := client.ReadKV(stringOrDefault(v.Mount, "secret"), v.Path, version)
+	if err != nil {
+		return "", "", fmt.Errorf("reading secret: %w", err)
+	}
+
+	username, err := secretValue(data, stringOrDefault(v.UsernameKey, "username"), false)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err := secretValue(data, stringOrDefault(v.PasswordKey, "password"), true)
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+// secretValue returns the string value of key in data. If required is false and data doesn't have the key, an empty
+// string is returned.
+func secretValue(data map[string]interface{}, key string, required bool) (string, error) {
+	raw, ok := data[key]
+	if !ok {
+		if required {
+			return "", fmt.Errorf("secret has no key '%s'", key)
+		}
+
+		return "", nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("value of key '%s' in secret is not a string", key)
+	}
+
+	return s, nil
+}
+
+func stringOrDefault(s, dfault string) string {
+	if s == "" {
+		return dfault
+	}
+
+	return s
+}
+
+func stringOrEnv(s, variable string) string {
+	if s == "" {
+		return os.Getenv(variable)
+	}
+
+	return s
+}
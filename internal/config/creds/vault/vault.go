@@ -0,0 +1,191 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Client makes requests to the Vault HTTP API.
+type Client struct {
+	Address   string // Address of the Vault server, for example https://vault.example.com:8200
+	Token     string // Token sent with each request
+	Namespace string // Namespace sent with each request, if not empty
+	HTTP      *http.Client
+}
+
+// NewClient returns a client for the Vault server at address. If caCert is not empty, it is the path to a PEM encoded
+// CA certificate which is trusted instead of the system certificates.
+func NewClient(address, caCert, namespace string) (*Client, error) {
+	c := &Client{
+		Address:   strings.TrimRight(address, "/"),
+		Namespace: namespace,
+		HTTP:      &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if caCert == "" {
+		return c, nil
+	}
+
+	caCert, err := homedir.Expand(caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	pem, err := ioutil.ReadFile(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caCert)
+	}
+
+	c.HTTP.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+	}
+
+	return c, nil
+}
+
+// TokenFromEnvironment returns the value of the VAULT_TOKEN environment variable or, if it isn't set, the contents of
+// ~/.vault-token written by 'vault login'. An empty string is returned if neither exist.
+func TokenFromEnvironment() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// LoginAppRole logs in with the AppRole auth method enabled at mount and sets the token of the client.
+func (c *Client) LoginAppRole(mount, roleID, secretID string) error {
+	body, err := json.Marshal(map[string]string{"role_id": roleID, "secret_id": secretID})
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Auth *struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+
+	if err := c.do(http.MethodPost, fmt.Sprintf("auth/%s/login", mount), body, &response); err != nil {
+		return fmt.Errorf("logging in with approle: %w", err)
+	}
+
+	if response.Auth == nil || response.Auth.ClientToken == "" {
+		return fmt.Errorf("logging in with approle: no token returned")
+	}
+
+	c.Token = response.Auth.ClientToken
+
+	return nil
+}
+
+// ReadKV returns the data of the secret at path in the KV secrets engine enabled at mount. version is the version of
+// the KV secrets engine, 1 or 2. Version 2 returns the latest version of the secret.
+func (c *Client) ReadKV(mount, path string, version int) (map[string]interface{}, error) {
+	mount = strings.Trim(mount, "/")
+	path = strings.Trim(path, "/")
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	switch version {
+	case 1:
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s/%s", mount, path), nil, &response); err != nil {
+			return nil, err
+		}
+
+		return response.Data, nil
+	case 2:
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s/data/%s", mount, path), nil, &response); err != nil {
+			return nil, err
+		}
+
+		data, ok := response.Data["data"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("secret %s/%s has no data, it may have been deleted", mount, path)
+		}
+
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("unsupported KV secrets engine version %d", version)
+}
+
+// do sends a request to the given API path and decodes the JSON response into v.
+func (c *Client) do(method, path string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", c.Address, path), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if c.Token != "" {
+		req.Header.Set("X-Vault-Token", c.Token)
+	}
+
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp.StatusCode, path, b)
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// responseError returns an error with the messages in the body of a failed request if there are any.
+func responseError(status int, path string, body []byte) error {
+	var errs struct {
+		Errors []string `json:"errors"`
+	}
+
+	if json.Unmarshal(body, &errs) == nil && len(errs.Errors) > 0 {
+		return fmt.Errorf("%s: %d %s: %s", path, status, http.StatusText(status), strings.Join(errs.Errors, ", "))
+	}
+
+	return fmt.Errorf("%s: %d %s", path, status, http.StatusText(status))
+}
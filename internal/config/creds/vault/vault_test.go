@@ -0,0 +1,206 @@
+package vault
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	testToken     = "s.testtoken"
+	testNamespace = "team"
+)
+
+// newServer returns a stand-in for the Vault API with a KV version 1 engine at kv/, a KV version 2 engine at secret/
+// and the AppRole auth method at approle/.
+func newServer(t *testing.T, tls bool) *httptest.Server {
+	secret := map[string]interface{}{"username": "vaultuser", "password": "vaultpassword"}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			if body["role_id"] != "myrole" || body["secret_id"] != "mysecret" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"` + testToken + `"}}`))
+			return
+		}
+
+		if r.Header.Get("X-Vault-Token") != testToken || r.Header.Get("X-Vault-Namespace") != testNamespace {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		var response interface{}
+		switch r.URL.Path {
+		case "/v1/kv/rdp/web":
+			response = map[string]interface{}{"data": secret}
+		case "/v1/secret/data/rdp/web":
+			response = map[string]interface{}{"data": map[string]interface{}{
+				"data":     secret,
+				"metadata": map[string]interface{}{"version": 3},
+			}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	var s *httptest.Server
+	if tls {
+		s = httptest.NewTLSServer(handler)
+	} else {
+		s = httptest.NewServer(handler)
+	}
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestClient_ReadKV(t *testing.T) {
+	s := newServer(t, false)
+
+	c, err := NewClient(s.URL, "", testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token = testToken
+
+	for _, tc := range []struct {
+		mount   string
+		version int
+	}{
+		{"kv", 1},
+		{"/secret/", 2},
+	} {
+		data, err := c.ReadKV(tc.mount, "rdp/web", tc.version)
+		if err != nil {
+			t.Errorf("unexpected error reading KV version %d secret: %s", tc.version, err)
+			continue
+		}
+
+		if data["username"] != "vaultuser" || data["password"] != "vaultpassword" {
+			t.Errorf("unexpected KV version %d secret data: %v", tc.version, data)
+		}
+	}
+
+	if _, err := c.ReadKV("secret", "rdp/missing", 2); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a not found error reading a missing secret: got %v", err)
+	}
+
+	c.Token = "s.wrong"
+	if _, err := c.ReadKV("secret", "rdp/web", 2); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected a permission denied error with an invalid token: got %v", err)
+	}
+}
+
+func TestClient_LoginAppRole(t *testing.T) {
+	s := newServer(t, false)
+
+	c, err := NewClient(s.URL, "", testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.LoginAppRole("approle", "myrole", "wrong"); err == nil {
+		t.Errorf("no error returned logging in with an invalid secret ID")
+	}
+
+	if err := c.LoginAppRole("approle", "myrole", "mysecret"); err != nil {
+		t.Fatalf("unexpected error logging in: %s", err)
+	}
+
+	if c.Token != testToken {
+		t.Errorf("unexpected token after logging in: %s", c.Token)
+	}
+}
+
+func TestNewClient_CACert(t *testing.T) {
+	s := newServer(t, true)
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	if err := ioutil.WriteFile(caCert, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	untrusted, err := NewClient(s.URL, "", testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrusted.Token = testToken
+
+	if _, err := untrusted.ReadKV("secret", "rdp/web", 2); err == nil {
+		t.Errorf("no error returned when the server certificate is not trusted")
+	}
+
+	c, err := NewClient(s.URL, caCert, testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token = testToken
+
+	if _, err := c.ReadKV("secret", "rdp/web", 2); err != nil {
+		t.Errorf("unexpected error with a custom CA: %s", err)
+	}
+}
+
+func TestTokenFromEnvironment(t *testing.T) {
+	home := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(home, ".vault-token"), []byte(testToken+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	setEnv(t, "HOME", home)
+	setEnv(t, "VAULT_TOKEN", "")
+
+	if token, err := TokenFromEnvironment(); err != nil || token != testToken {
+		t.Errorf("unexpected token read from ~/.vault-token: '%s', %v", token, err)
+	}
+
+	setEnv(t, "VAULT_TOKEN", "s.envtoken")
+
+	if token, err := TokenFromEnvironment(); err != nil || token != "s.envtoken" {
+		t.Errorf("unexpected token read from VAULT_TOKEN: '%s', %v", token, err)
+	}
+}
+
+// setEnv sets an environment variable for the duration of the test.
+func setEnv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// DefaultConfigName is the name of the config file new entries are written to if no other file is given.
+const DefaultConfigName = "config.toml"
+
+var (
+	headerPattern  = regexp.MustCompile(`^\s*\[([^\[\]]+)\]\s*(#.*)?$`)
+	commentPattern = regexp.MustCompile(`^\s*#`)
+)
+
+// File is the text of a config file which can be edited one entry at a time without changing the comments or
+// formatting of any other entries.
+type File struct {
+	lines []string
+}
+
+// NewFile returns a File with the given text.
+func NewFile(text string) *File {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return &File{lines: []string{}}
+	}
+
+	return &File{lines: strings.Split(text, "\n")}
+}
+
+// String returns the text of the file.
+func (f *File) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// Check returns an error if the text of the file can't be parsed as TOML. Edits are checked before they are written
+// so a mistake never leaves a config which can't be loaded.
+func (f *File) Check() error {
+	if _, err := toml.Load(f.String()); err != nil {
+		return fmt.Errorf("the edited file would not be valid TOML: %w", err)
+	}
+
+	return nil
+}
+
+// HasEntry returns true if the file contains a table with the given key.
+func (f *File) HasEntry(key string) bool {
+	_, _, _, ok := f.section(key)
+	return ok
+}
+
+// EntryText returns the text of the entry with the given key, including any comments directly above it.
+func (f *File) EntryText(key string) (string, error) {
+	start, _, end, ok := f.section(key)
+	if !ok {
+		return "", fmt.Errorf("entry %s not found", key)
+	}
+
+	return strings.Join(trimBlankLines(f.lines[start:end]), "\n") + "\n", nil
+}
+
+// AppendEntry adds an entry to the end of the file, separated from existing content by a blank line.
+func (f *File) AppendEntry(e Entry) {
+	if len(f.lines) > 0 {
+		f.lines = append(f.lines, "")
+	}
+
+	f.lines = append(f.lines, strings.Split(strings.TrimRight(e.TOML(), "\n"), "\n")...)
+}
+
+// RemoveEntry removes the entry with the given key, including any comments directly above it.
+func (f *File) RemoveEntry(key string) error {
+	start, _, end, ok := f.section(key)
+	if !ok {
+		return fmt.Errorf("entry %s not found", key)
+	}
+
+	// Remove the blank lines separating the entry from the one before it
+	for start > 0 && strings.TrimSpace(f.lines[start-1]) == "" {
+		start--
+	}
+
+	// Keep a single blank line if there is content before and after the entry
+	replacement := []string{}
+	if start > 0 && end < len(f.lines) {
+		replacement = []string{""}
+		for end < len(f.lines) && strings.TrimSpace(f.lines[end]) == "" {
+			end++
+		}
+	}
+
+	f.lines = append(f.lines[:start], append(replacement, f.lines[end:]...)...)
+
+	return nil
+}
+
+// RenameEntry replaces the key in the header of an entry.
+func (f *File) RenameEntry(key, newKey string) error {
+	_, header, _, ok := f.section(key)
+	if !ok {
+		return fmt.Errorf("entry %s not found", key)
+	}
+
+	comment := headerPattern.FindStringSubmatch(f.lines[header])[2]
+	if comment != "" {
+		comment = " " + comment
+	}
+
+	f.lines[header] = fmt.Sprintf("[%s]%s", newKey, comment)
+
+	return nil
+}
+
+// SetField sets the value of a field in an entry. An existing field is replaced in place, preserving its indentation,
+// otherwise the field is added after the last field in the entry.
+func (f *File) SetField(key, field string, value interface{}) error {
+	_, header, end, ok := f.section(key)
+	if !ok {
+		return fmt.Errorf("entry %s not found", key)
+	}
+
+	indent := "    "
+	line := fmt.Sprintf("%s = %s", field, tomlValue(value))
+
+	if start, stop, found := f.field(header+1, end, field); found {
+		indent = leadingWhitespace(f.lines[start])
+		f.lines = append(f.lines[:start], append([]string{indent + line}, f.lines[stop:]...)...)
+
+		return nil
+	}
+
+	// Insert after the last non-blank, non-comment line in the entry
+	insert := header + 1
+	for i := end - 1; i > header; i-- {
+		if t := strings.TrimSpace(f.lines[i]); t != "" && !commentPattern.MatchString(t) {
+			insert = i + 1
+			indent = leadingWhitespace(f.lines[f.firstField(header+1, end)])
+			break
+		}
+	}
+
+	f.lines = append(f.lines[:insert], append([]string{indent + line}, f.lines[insert:]...)...)
+
+	return nil
+}
+
+// RemoveField removes a field from an entry. It is not an error if the field doesn't exist.
+func (f *File) RemoveField(key, field string) error {
+	_, header, end, ok := f.section(key)
+	if !ok {
+		return fmt.Errorf("entry %s not found", key)
+	}
+
+	if start, stop, found := f.field(header+1, end, field); found {
+		f.lines = append(f.lines[:start], f.lines[stop:]...)
+	}
+
+	return nil
+}
+
+// section returns the line indices of the start of an entry including comments directly above it, its header and the
+// end of the entry.
+func (f *File) section(key string) (int, int, int, bool) {
+	headers := f.headers()
+
+	for i, h := range headers {
+		if normaliseKey(headerPattern.FindStringSubmatch(f.lines[h])[1]) != strings.ToLower(key) {
+			continue
+		}
+
+		end := len(f.lines)
+		if i+1 < len(headers) {
+			end = f.commentStart(headers[i+1])
+		}
+
+		return f.commentStart(h), h, end, true
+	}
+
+	return 0, 0, 0, false
+}
+
+// headers returns the line indices of all table headers, ignoring any lines inside multi-line values.
+func (f *File) headers() []int {
+	headers := make([]int, 0)
+	var state scanState
+
+	for i, l := range f.lines {
+		if !state.open() && headerPattern.MatchString(l) {
+			headers = append(headers, i)
+			continue
+		}
+
+		state = scanLine(l, state)
+	}
+
+	return headers
+}
+
+// field returns the line range of a field between start and end. The range spans multiple lines if the value is a
+// multi-line string, array or inline table.
+func (f *File) field(start, end int, name string) (int, int, bool) {
+	pattern := regexp.MustCompile(fmt.Sprintf(`^\s*"?%s"?\s*=`, regexp.QuoteMeta(name)))
+	var state scanState
+
+	for i := start; i < end; i++ {
+		if !state.open() && pattern.MatchString(strings.ToLower(f.lines[i])) {
+			stop := i + 1
+			value := scanLine(f.lines[i], scanState{})
+			for value.open() && stop < end {
+				value = scanLine(f.lines[stop], value)
+				stop++
+			}
+
+			return i, stop, true
+		}
+
+		state = scanLine(f.lines[i], state)
+	}
+
+	return 0, 0, false
+}
+
+// firstField returns the index of the first field line between start and end, or start if there are none.
+func (f *File) firstField(start, end int) int {
+	for i := start; i < end; i++ {
+		if t := strings.TrimSpace(f.lines[i]); t != "" && !commentPattern.MatchString(t) {
+			return i
+		}
+	}
+
+	return start
+}
+
+// commentStart returns the index of the first line of the comment block directly above the given line.
+func (f *File) commentStart(line int) int {
+	for line > 0 && commentPattern.MatchString(f.lines[line-1]) {
+		line--
+	}
+
+	return line
+}
+
+// scanState is the state of a value which continues onto the next line.
+type scanState struct {
+	str   string // The delimiter of the open multi-line string, if any
+	depth int    // The number of open arrays and inline tables
+}
+
+// open returns true if a value is still open at the end of the line.
+func (s scanState) open() bool {
+	return s.str != "" || s.depth > 0
+}
+
+// scanLine returns the state of a value after the given line. state is the state before the line. Brackets and braces
+// in strings and comments are ignored.
+func scanLine(line string, state scanState) scanState {
+	for i := 0; i < len(line); i++ {
+		if state.str != "" {
+			switch {
+			case state.str == `"""` && line[i] == '\\':
+				i++
+			case strings.HasPrefix(line[i:], state.str):
+				i += len(state.str) - 1
+				state.str = ""
+			}
+
+			continue
+		}
+
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], `'''`):
+			state.str = line[i : i+3]
+			i += 2
+		case c == '"':
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case c == '\'':
+			for i++; i < len(line) && line[i] != '\''; i++ {
+			}
+		case c == '#':
+			return state
+		case c == '[' || c == '{':
+			state.depth++
+		case (c == ']' || c == '}') && state.depth > 0:
+			state.depth--
+		}
+	}
+
+	return state
+}
+
+// normaliseKey removes quotes and whitespace from a dotted TOML key and makes it lower case.
+func normaliseKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+
+	return strings.ToLower(strings.Join(parts, "."))
+}
+
+func leadingWhitespace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
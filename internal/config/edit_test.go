@@ -0,0 +1,232 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+const testEditFile = `# Web servers
+[host.awsec2.web]
+  # instance id
+  id = "i-123"
+  filterjson = """
+  [host.basic.notaheader]
+  """
+
+[settings.small] # small screens
+	width = 800
+
+[host.basic.jump]
+    address = "1.2.3.4"
+`
+
+func TestFile_RemoveEntry(t *testing.T) {
+	f := NewFile(testEditFile)
+
+	if err := f.RemoveEntry("settings.small"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `# Web servers
+[host.awsec2.web]
+  # instance id
+  id = "i-123"
+  filterjson = """
+  [host.basic.notaheader]
+  """
+
+[host.basic.jump]
+    address = "1.2.3.4"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected file after removing entry: want:\n%s\ngot:\n%s", want, got)
+	}
+
+	// Removing the first entry also removes the comment above it
+	if err := f.RemoveEntry("host.awsec2.web"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want = `[host.basic.jump]
+    address = "1.2.3.4"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected file after removing entry: want:\n%s\ngot:\n%s", want, got)
+	}
+
+	if err := f.RemoveEntry("host.basic.notaheader"); err == nil {
+		t.Errorf("no error returned when removing a table header inside a multi-line string")
+	}
+}
+
+func TestFile_RenameEntry(t *testing.T) {
+	f := NewFile(testEditFile)
+
+	if err := f.RenameEntry("settings.small", "settings.tiny"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if f.HasEntry("settings.small") || !f.HasEntry("settings.tiny") {
+		t.Fatalf("entry was not renamed:\n%s", f)
+	}
+
+	text, _ := f.EntryText("settings.tiny")
+	if want := "[settings.tiny] # small screens\n\twidth = 800\n"; text != want {
+		t.Errorf("unexpected entry text: want:\n%s\ngot:\n%s", want, text)
+	}
+}
+
+func TestFile_SetField(t *testing.T) {
+	f := NewFile(testEditFile)
+
+	// Replace an existing field, keeping its indentation
+	if err := f.SetField("settings.small", "width", int64(1024)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Add a new field after the last field using the indentation of the first field
+	if err := f.SetField("settings.small", "height", int64(768)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	text, _ := f.EntryText("settings.small")
+	if want := "[settings.small] # small screens\n\twidth = 1024\n\theight = 768\n"; text != want {
+		t.Errorf("unexpected entry text: want:\n%s\ngot:\n%s", want, text)
+	}
+
+	// Replace a multi-line string
+	if err := f.SetField("host.awsec2.web", "filterjson", "[]"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	text, _ = f.EntryText("host.awsec2.web")
+	if want := "# Web servers\n[host.awsec2.web]\n  # instance id\n  id = \"i-123\"\n  filterjson = \"[]\"\n"; text != want {
+		t.Errorf("unexpected entry text: want:\n%s\ngot:\n%s", want, text)
+	}
+
+	if err := f.SetField("settings.missing", "width", int64(800)); err == nil {
+		t.Errorf("no error returned when setting a field in an entry which doesn't exist")
+	}
+}
+
+func TestFile_SetField_MultilineValues(t *testing.T) {
+	f := NewFile(`[cred.exec.op]
+    command = "op"
+    args = [
+        "item", # a comment with ] in it
+        "get]",
+    ]
+    env = { a = "{",
+        b = "c" }
+    timeout = 10
+`)
+
+	if err := f.SetField("cred.exec.op", "args", []interface{}{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := f.RemoveField("cred.exec.op", "env"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "[cred.exec.op]\n    command = \"op\"\n    args = [\"a\", \"b\"]\n    timeout = 10\n"
+	if f.String() != want {
+		t.Errorf("unexpected file text: want:\n%s\ngot:\n%s", want, f)
+	}
+
+	if err := f.Check(); err != nil {
+		t.Errorf("unexpected error checking the edited file: %s", err)
+	}
+
+	if err := NewFile("[a]\nb = [\n").Check(); err == nil {
+		t.Errorf("no error returned checking invalid TOML")
+	}
+}
+
+func TestFile_RemoveField(t *testing.T) {
+	f := NewFile(testEditFile)
+
+	if err := f.RemoveField("host.awsec2.web", "filterjson"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	text, _ := f.EntryText("host.awsec2.web")
+	if want := "# Web servers\n[host.awsec2.web]\n  # instance id\n  id = \"i-123\"\n"; text != want {
+		t.Errorf("unexpected entry text: want:\n%s\ngot:\n%s", want, text)
+	}
+
+	// The next entry is unchanged
+	if !f.HasEntry("settings.small") {
+		t.Errorf("following entry was removed:\n%s", f)
+	}
+}
+
+func TestParseFieldValue(t *testing.T) {
+	for _, c := range []struct {
+		key, field, value string
+		want              interface{}
+	}{
+		{"settings.small", "width", "800", int64(800)},
+		{"settings.small", "fullscreen", "true", true},
+		{"settings.small", "redirectclipboard", "false", false},
+		{"host.awsec2.web", "region", "eu-west-2", "eu-west-2"},
+		{"host.awsec2.web", "cred", "mycred", "mycred"},
+	} {
+		got, err := ParseFieldValue(c.key, c.field, c.value)
+		if err != nil {
+			t.Errorf("unexpected error parsing %s in %s: %s", c.field, c.key, err)
+			continue
+		}
+
+		if got != c.want {
+			t.Errorf("unexpected value for %s in %s: want %v (%T): got %v (%T)", c.field, c.key, c.want, c.want, got, got)
+		}
+	}
+
+	got, err := ParseFieldValue("cred.awssm.mycred", "usernameid", "a")
+	if err != nil || got != "a" {
+		t.Errorf("unexpected result parsing cred field: %v %s", got, err)
+	}
+
+	if _, err := ParseFieldValue("settings.small", "width", "wide"); !errors.Is(err, &FieldLoadError{}) {
+		t.Errorf("unexpected error for invalid integer: want FieldLoadError: got %v", err)
+	}
+
+	if _, err := ParseFieldValue("host.nope.web", "address", "x"); err == nil {
+		t.Errorf("no error returned for invalid host type")
+	}
+}
+
+func TestValidateEntry(t *testing.T) {
+	if err := ValidateEntry("settings.small", map[string]interface{}{"width": int64(800)}); err != nil {
+		t.Errorf("unexpected error for valid entry: %s", err)
+	}
+
+	if err := ValidateEntry("settings.small", map[string]interface{}{"width": int64(9)}); !errors.Is(err, &InvalidConfigError{}) {
+		t.Errorf("unexpected error for invalid width: want InvalidConfigError: got %v", err)
+	}
+
+	if err := ValidateEntry("host.basic.web", map[string]interface{}{"nope": "x"}); err == nil {
+		t.Errorf("no error returned for unknown field")
+	}
+
+	for _, key := range []string{"host.basic.my host", "host.basic.a]b", `settings.a"b`, "tunnel.a=b"} {
+		if err := ValidateEntry(key, map[string]interface{}{}); err == nil {
+			t.Errorf("no error returned for invalid entry name in %s", key)
+		}
+	}
+}
+
+func TestValidateEntryName(t *testing.T) {
+	for _, name := range []string{"web", "web-1", "web_2", "0"} {
+		if err := ValidateEntryName(name); err != nil {
+			t.Errorf("unexpected error for valid name '%s': %s", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "my host", "a]b", "a.b", "Web"} {
+		if err := ValidateEntryName(name); err == nil {
+			t.Errorf("no error returned for invalid name '%s'", name)
+		}
+	}
+}
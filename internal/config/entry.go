@@ -0,0 +1,410 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config/creds"
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+
+	"github.com/spf13/viper"
+)
+
+// Entry is a single config entry which can be written to a config file.
+type Entry struct {
+	Key    string                 // Full key of the entry, for example host.basic.myhost
+	Fields map[string]interface{} // Field values of type string, bool, int, int64 or []string
+}
+
+// TOML returns the entry as a TOML table with its fields in alphabetical order.
+func (e Entry) TOML() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("[%s]\n", e.Key))
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("    %s = %s\n", k, tomlValue(e.Fields[k])))
+	}
+
+	return b.String()
+}
+
+// Kind returns the first part of the entry key, for example 'host'.
+func (e Entry) Kind() string {
+	return strings.SplitN(e.Key, ".", 2)[0]
+}
+
+// Name returns the last part of the entry key, which is the user defined name of the entry.
+func (e Entry) Name() string {
+	parts := strings.Split(e.Key, ".")
+	return parts[len(parts)-1]
+}
+
+// ID returns the kind and name of the entry, for example host.myhost, which identify it among the entries of every
+// type. Templates of different host types may have the same name, so a template is identified by its key.
+func (e Entry) ID() string {
+	if e.Kind() == "template" {
+		return e.Key
+	}
+
+	return e.Kind() + "." + e.Name()
+}
+
+// EntriesTOML returns the given entries as TOML tables separated by blank lines.
+func EntriesTOML(entries []Entry) string {
+	tables := make([]string, len(entries))
+	for i, e := range entries {
+		tables[i] = e.TOML()
+	}
+
+	return strings.Join(tables, "\n")
+}
+
+func tomlValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return tomlString(t)
+	case []string:
+		items := make([]string, len(t))
+		for i, s := range t {
+			items[i] = tomlString(s)
+		}
+
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	case []interface{}:
+		items := make([]string, len(t))
+		for i, v := range t {
+			items[i] = tomlValue(v)
+		}
+
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// tomlString returns s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+
+	b.WriteRune('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			b.WriteString(fmt.Sprintf(`\u%04X`, r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune('"')
+
+	return b.String()
+}
+
+// EntryKeys returns the keys of all entries in v in alphabetical order, for example host.basic.myhost.
+func EntryKeys(v *viper.Viper) []string {
+	keys := make([]string, 0)
+
+	for _, kind := range []string{"host", "cred", "template"} {
+		for subType, entries := range v.GetStringMap(kind) {
+			if m, ok := entries.(map[string]interface{}); ok {
+				for name := range m {
+					keys = append(keys, fmt.Sprintf("%s.%s.%s", kind, subType, name))
+				}
+			}
+		}
+	}
+
+	for _, kind := range []string{"settings", "tunnel", "group"} {
+		for name := range v.GetStringMap(kind) {
+			keys = append(keys, fmt.Sprintf("%s.%s", kind, name))
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// entryTypeFunc returns the function which creates an empty struct for the entry with the given key.
+func entryTypeFunc(key string) (func() interface{}, error) {
+	parts := strings.Split(key, ".")
+
+	switch {
+	case parts[0] == "host" && len(parts) == 3:
+		if f, ok := hosts.Map[parts[1]]; ok {
+			return f, nil
+		}
+
+		return nil, fmt.Errorf("'%s' is not a host type, must be one of %s", parts[1], mapKeys(hosts.Map))
+	case parts[0] == "template" && len(parts) == 3:
+		if f, ok := hosts.Map[parts[1]]; ok {
+			return f, nil
+		}
+
+		return nil, fmt.Errorf("'%s' is not a host type, must be one of %s", parts[1], mapKeys(hosts.Map))
+	case parts[0] == "cred" && len(parts) == 3:
+		if f, ok := creds.Map[parts[1]]; ok {
+			return f, nil
+		}
+
+		return nil, fmt.Errorf("'%s' is not a cred type, must be one of %s", parts[1], mapKeys(creds.Map))
+	case parts[0] == "settings" && len(parts) == 2:
+		return func() interface{} { return &Settings{} }, nil
+	case parts[0] == "tunnel" && len(parts) == 2:
+		return func() interface{} { return &Tunnel{} }, nil
+	case parts[0] == "group" && len(parts) == 2:
+		return func() interface{} { return &Group{} }, nil
+	}
+
+	return nil, fmt.Errorf("'%s' is not a valid entry key, expected host.<type>.<name>, cred.<type>.<name>, "+
+		"template.<type>.<name>, settings.<name>, tunnel.<name> or group.<name>", key)
+}
+
+// ParseFieldValue converts a string to the type of a field in the entry with the given key. Array values are comma
+// separated.
+func ParseFieldValue(key, field, value string) (interface{}, error) {
+	typeFunc, err := entryTypeFunc(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if isHostKey(key) && (hosts.FieldNameIsGlobal(field) || field == ExtendsField) {
+		return value, nil
+	}
+
+	if isHostKey(key) && field == TagsField {
+		return splitList(value), nil
+	}
+
+	t := reflect.ValueOf(typeFunc()).Elem()
+	valueMap := make(map[string]reflect.Value)
+	mapFields(t, valueMap)
+
+	v, ok := valueMap[field]
+	if !ok {
+		return nil, fmt.Errorf("config key %s is invalid for type %s", field, t.Type().Name())
+	}
+
+	kind := v.Kind()
+	if kind == reflect.Ptr {
+		kind = v.Type().Elem().Kind()
+	}
+
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, &FieldLoadError{ConfigName: key, FieldName: field, Message: "expected value of type bool"}
+		}
+
+		return b, nil
+	case reflect.Int:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, &FieldLoadError{ConfigName: key, FieldName: field, Message: "expected value of type integer"}
+		}
+
+		return i, nil
+	case reflect.Slice:
+		return splitList(value), nil
+	}
+
+	return value, nil
+}
+
+// splitList splits a comma separated list and trims whitespace from each item.
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	return items
+}
+
+// ValidateEntry parses the fields of the entry with the given key in the same way as entries in config files and
+// validates the result. The entry name is also validated (see ValidateEntryName).
+func ValidateEntry(key string, fields map[string]interface{}) error {
+	if err := ValidateEntryName(Entry{Key: key}.Name()); err != nil {
+		return err
+	}
+
+	typeFunc, err := entryTypeFunc(key)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	for k, v := range fields {
+		if isHostKey(key) && k == ExtendsField {
+			if _, ok := v.(string); !ok {
+				return &FieldLoadError{ConfigName: key, FieldName: k, Message: "expected value of type string"}
+			}
+
+			continue
+		}
+
+		switch t := v.(type) {
+		case int:
+			data[k] = int64(t)
+		case []string:
+			items := make([]interface{}, len(t))
+			for i := range t {
+				items[i] = t[i]
+			}
+			data[k] = items
+		default:
+			data[k] = v
+		}
+	}
+
+	if isHostKey(key) {
+		if _, err := hostTags(data, key); err != nil {
+			return err
+		}
+
+		delete(data, TagsField)
+	}
+
+	entry := typeFunc()
+	if err := setFields(reflect.ValueOf(entry).Elem(), data); err != nil {
+		return err
+	}
+
+	if isHostKey(key) {
+		if _, err := getGlobals(data); err != nil {
+			return err
+		}
+	}
+
+	validator, ok := entry.(interface{ Validate() error })
+	if !ok {
+		return nil
+	}
+
+	if err := validator.Validate(); err != nil {
+		return &InvalidConfigError{Reason: fmt.Errorf("%s configuration is invalid: %w", key, err)}
+	}
+
+	return nil
+}
+
+// isHostKey returns true if key is the key of a host or template entry, which may have global fields.
+func isHostKey(key string) bool {
+	return strings.HasPrefix(key, "host.") || strings.HasPrefix(key, "template.")
+}
+
+func mapKeys(m map[string]func() interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return strings.Join(keys, ", ")
+}
+
+// EntryFields returns the parsed values of the entry with the given key for display. Fields with zero values are
+// omitted and the values of fields tagged `secret:"true"` are replaced with MaskedValue. Host entries include any
+// global fields which are set.
+func (c *Configuration) EntryFields(key string) (map[string]interface{}, bool) {
+	e := Entry{Key: key}
+	name := e.Name()
+
+	var entry interface{}
+	var ok bool
+
+	switch e.Kind() {
+	case "host":
+		entry, ok = c.Hosts[name]
+	case "cred":
+		entry, ok = c.Creds[name]
+	case "settings":
+		var s Settings
+		s, ok = c.Settings[name]
+		entry = &s
+	case "tunnel":
+		var t Tunnel
+		t, ok = c.Tunnels[name]
+		entry = &t
+	case "group":
+		var g Group
+		g, ok = c.Groups[name]
+		entry = &g
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	fields := make(map[string]interface{})
+	structFields(reflect.Indirect(reflect.ValueOf(entry)), fields)
+
+	if e.Kind() == "host" {
+		for k, v := range c.HostGlobals[name] {
+			if v != "" {
+				fields[k] = v
+			}
+		}
+
+		if len(c.HostTags[name]) > 0 {
+			fields[TagsField] = c.HostTags[name]
+		}
+	}
+
+	return fields, true
+}
+
+// MaskedValue replaces the values of secret fields returned by EntryFields.
+const MaskedValue = "********"
+
+// structFields adds the exported, non-zero fields of a struct to fields by their lower case names. Pointers are
+// dereferenced and the fields of embedded structs are added as if they belonged to the outer struct. Secret fields
+// are masked.
+func structFields(values reflect.Value, fields map[string]interface{}) {
+	structType := values.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		v := values.Field(i)
+
+		if field.Anonymous && v.Kind() == reflect.Struct {
+			structFields(v, fields)
+			continue
+		}
+
+		if v.IsZero() {
+			continue
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			fields[strings.ToLower(field.Name)] = MaskedValue
+			continue
+		}
+
+		fields[strings.ToLower(field.Name)] = reflect.Indirect(v).Interface()
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestConfiguration_EntryFields(t *testing.T) {
+	c, err := New(vipersFromString(`
+[cred.vault.mycred]
+    path = "rdp/web"
+    token = "s.abcdef"
+
+[cred.tss.tsscred]
+    server = "https://example.com/SecretServer"
+    apiuser = "api"
+    apipassword = "hunter2"
+    secretid = 12`), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for key, want := range map[string]map[string]interface{}{
+		"cred.vault.mycred": {"path": "rdp/web", "token": MaskedValue},
+		"cred.tss.tsscred": {"server": "https://example.com/SecretServer", "apiuser": "api",
+			"apipassword": MaskedValue, "secretid": 12},
+	} {
+		fields, ok := c.EntryFields(key)
+		if !ok {
+			t.Fatalf("entry %s not found", key)
+		}
+
+		for k, v := range want {
+			if fields[k] != v {
+				t.Errorf("unexpected value of %s.%s: want %v: got %v", key, k, v, fields[k])
+			}
+		}
+	}
+}
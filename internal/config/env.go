@@ -0,0 +1,289 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvKey is the name of the top level table which contains an overlay table for each environment.
+const EnvKey = "env"
+
+// EnvDirectory is the name of the directory in the config root which contains a directory of config files for each
+// environment. It is not read when the config root is read recursively.
+const EnvDirectory = "env"
+
+// Environments returns the names of the environments which have an [env.<name>] overlay in any of vipers, in
+// alphabetical order.
+func Environments(vipers map[string]*viper.Viper) []string {
+	names := make(map[string]bool)
+
+	for _, v := range vipers {
+		for name := range v.GetStringMap(EnvKey) {
+			names[name] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// ReplaceEntries returns files with the entries which are also defined in envFiles removed, together with envFiles.
+// This lets the config files in an environment directory redefine entries in the other config files. An entry is
+// replaced by an entry of the same kind and name, which may be of a different type. files is not changed.
+func ReplaceEntries(files, envFiles map[string]*viper.Viper) (map[string]*viper.Viper, error) {
+	replaced := make(map[string]bool)
+	for _, v := range envFiles {
+		for _, key := range EntryKeys(v) {
+			replaced[Entry{Key: key}.ID()] = true
+		}
+	}
+
+	result := make(map[string]*viper.Viper, len(files)+len(envFiles))
+
+	for f, v := range files {
+		result[f] = v
+
+		settings := v.AllSettings()
+		changed := false
+
+		for _, key := range EntryKeys(v) {
+			if replaced[Entry{Key: key}.ID()] {
+				deleteNestedMap(settings, strings.Split(key, "."))
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		result[f] = viper.New()
+		if err := result[f].MergeConfigMap(settings); err != nil {
+			return nil, err
+		}
+	}
+
+	for f, v := range envFiles {
+		result[f] = v
+	}
+
+	return result, nil
+}
+
+// ApplyEnvironment returns a copy of vipers with the [env.<name>] overlays of the named environment applied.
+//
+// Fields directly in the overlay table, for example 'profile = "prod"', replace that field in every host, template and
+// cred entry which sets it. Entries which don't set the field are unchanged. Tables in the overlay which have the key of an entry, for example
+// [env.prod.tunnel.mytunnel], replace the fields of that entry. If there is no such entry, it is added.
+func ApplyEnvironment(vipers map[string]*viper.Viper, name string) (map[string]*viper.Viper, error) {
+	files := make([]string, 0, len(vipers))
+	for f := range vipers {
+		files = append(files, f)
+	}
+
+	sort.Strings(files)
+
+	common := make(map[string]interface{}) // Fields applied to all entries which have them
+	entries := make(map[string]envEntry)   // Fields applied to single entries by entry key
+	settings := make(map[string]map[string]interface{})
+
+	for _, f := range files {
+		settings[f] = vipers[f].AllSettings()
+
+		overlay, ok := nestedMap(settings[f], EnvKey, name)
+		if !ok {
+			continue
+		}
+
+		if err := readOverlay(overlay, f, common, entries); err != nil {
+			return nil, fmt.Errorf("%s: %s.%s: %w", f, EnvKey, name, err)
+		}
+	}
+
+	applied := make(map[string]bool)
+	commonUsed := make(map[string]bool)
+
+	for _, f := range files {
+		for _, key := range EntryKeys(vipers[f]) {
+			fields, _ := nestedMap(settings[f], strings.Split(key, ".")...)
+
+			for _, u := range applyCommonFields(key, fields, common) {
+				commonUsed[u] = true
+			}
+
+			if e, ok := entries[key]; ok {
+				for k, v := range e.fields {
+					fields[k] = v
+				}
+
+				applied[key] = true
+			}
+		}
+	}
+
+	for field := range common {
+		if !commonUsed[field] {
+			return nil, fmt.Errorf("%s.%s: '%s' is not set in any host, template or cred entry", EnvKey, name, field)
+		}
+	}
+
+	// Overlays for entries which don't exist add the entry to the file which defines the overlay
+	for key, e := range entries {
+		if applied[key] {
+			continue
+		}
+
+		if _, err := entryTypeFunc(key); err != nil {
+			return nil, fmt.Errorf("%s: %s.%s: %w", e.file, EnvKey, name, err)
+		}
+
+		setNestedMap(settings[e.file], strings.Split(key, "."), e.fields)
+	}
+
+	result := make(map[string]*viper.Viper)
+	for _, f := range files {
+		v := viper.New()
+		if err := v.MergeConfigMap(settings[f]); err != nil {
+			return nil, err
+		}
+
+		result[f] = v
+	}
+
+	return result, nil
+}
+
+// envEntry is an overlay for a single config entry.
+type envEntry struct {
+	file   string // The file the overlay was read from
+	fields map[string]interface{}
+}
+
+// readOverlay adds the fields of an environment overlay table to common and its entry tables to entries.
+func readOverlay(overlay map[string]interface{}, file string, common map[string]interface{},
+	entries map[string]envEntry) error {
+	for k, v := range overlay {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			common[k] = v
+			continue
+		}
+
+		if !isEntryKind(k) {
+			return fmt.Errorf("'%s' is not a config type, must be one of %s", k, strings.Join(EntryKinds, ", "))
+		}
+
+		depth := 2
+		if k == "host" || k == "cred" || k == "template" {
+			depth = 3
+		}
+
+		for key, fields := range flattenTables(k, m, depth-1) {
+			entries[key] = envEntry{file: file, fields: fields}
+		}
+	}
+
+	return nil
+}
+
+// flattenTables returns the tables nested depth levels deep in m by their full key.
+func flattenTables(prefix string, m map[string]interface{}, depth int) map[string]map[string]interface{} {
+	tables := make(map[string]map[string]interface{})
+
+	for k, v := range m {
+		sub, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s.%s", prefix, k)
+
+		if depth == 1 {
+			tables[key] = sub
+			continue
+		}
+
+		for nestedKey, t := range flattenTables(key, sub, depth-1) {
+			tables[nestedKey] = t
+		}
+	}
+
+	return tables
+}
+
+// applyCommonFields replaces each field in common which is set in the fields of the entry with the given key. Only
+// host, template and cred entries are changed. The names of the fields which were replaced are returned.
+func applyCommonFields(key string, fields, common map[string]interface{}) []string {
+	used := make([]string, 0)
+
+	e := Entry{Key: key}
+	if e.Kind() != "host" && e.Kind() != "template" && e.Kind() != "cred" {
+		return used
+	}
+
+	for k, v := range common {
+		if _, ok := fields[k]; !ok {
+			continue
+		}
+
+		fields[k] = v
+		used = append(used, k)
+	}
+
+	return used
+}
+
+func isEntryKind(kind string) bool {
+	for _, k := range EntryKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nestedMap returns the map found by following keys through nested maps in m.
+func nestedMap(m map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	for _, k := range keys {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		m = next
+	}
+
+	return m, true
+}
+
+// deleteNestedMap deletes the value in m at the path given by keys, if it exists.
+func deleteNestedMap(m map[string]interface{}, keys []string) {
+	if parent, ok := nestedMap(m, keys[:len(keys)-1]...); ok {
+		delete(parent, keys[len(keys)-1])
+	}
+}
+
+// setNestedMap sets value in m at the path given by keys, creating any maps which don't exist.
+func setNestedMap(m map[string]interface{}, keys []string, value map[string]interface{}) {
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[k] = next
+		}
+
+		m = next
+	}
+
+	m[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config/creds"
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+)
+
+func TestApplyEnvironment(t *testing.T) {
+	v := vipersFromStrings([]string{`
+[cred.awssm.mycred]
+    usernameid = "user"
+    profile = "dev"
+
+[host.awsec2.web]
+    id = "i-12345abc"
+    profile = "dev"
+    region = "eu-west-2"
+    tunnel = "devtunnel"
+
+[host.basic.jump]
+    address = "10.0.0.1"
+
+[tunnel.devtunnel]
+    host = "jump"
+    localport = "3390"`, `
+[env.prod]
+    profile = "prod"
+
+[env.prod.host.awsec2.web]
+    tunnel = "prodtunnel"
+
+[env.prod.tunnel.prodtunnel]
+    host = "jump"
+    localport = "3391"
+
+[env.staging]
+    region = "eu-west-1"`,
+	})
+
+	if got := Environments(v); strings.Join(got, ",") != "prod,staging" {
+		t.Errorf("unexpected environments: %s", got)
+	}
+
+	prod, err := ApplyEnvironment(v, "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c, err := New(prod, true)
+	if err != nil {
+		t.Fatalf("unexpected error parsing environment: %s", err)
+	}
+
+	web := c.Hosts["web"].(*hosts.EC2)
+	if web.Profile != "prod" || web.Region != "eu-west-2" {
+		t.Errorf("unexpected host fields in environment: %+v", web)
+	}
+	if got := c.HostGlobals["web"]["tunnel"]; got != "prodtunnel" {
+		t.Errorf("unexpected tunnel in environment: want 'prodtunnel': got '%s'", got)
+	}
+	if got := c.Creds["mycred"].(*creds.SecretsManager).Profile; got != "prod" {
+		t.Errorf("unexpected cred profile in environment: want 'prod': got '%s'", got)
+	}
+	if got := c.Tunnels["prodtunnel"].LocalPort; got != "3391" {
+		t.Errorf("tunnel added by environment was not loaded: got local port '%s'", got)
+	}
+
+	// The original config is unchanged
+	c, err = New(v, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := c.Hosts["web"].(*hosts.EC2).Profile; got != "dev" {
+		t.Errorf("original config was changed: got profile '%s'", got)
+	}
+
+	if _, err := ApplyEnvironment(vipersFromString(`
+[host.basic.jump]
+    address = "10.0.0.1"
+
+[env.prod]
+    notafield = "x"`), "prod"); err == nil {
+		t.Errorf("no error returned for an environment field which isn't a field of any entry")
+	}
+}
+
+func TestApplyEnvironment_CommonFields(t *testing.T) {
+	v := vipersFromString(`
+[host.basic.web]
+    address = "10.0.0.2"
+    tunnel = "devtunnel"
+
+[host.basic.jump]
+    address = "10.0.0.1"
+
+[tunnel.devtunnel]
+    host = "jump"
+    localport = "3390"
+
+[tunnel.prodtunnel]
+    host = "jump"
+    localport = "3391"
+
+[env.prod]
+    tunnel = "prodtunnel"`)
+
+	prod, err := ApplyEnvironment(v, "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c, err := New(prod, true)
+	if err != nil {
+		t.Fatalf("unexpected error parsing environment: %s", err)
+	}
+
+	// Only entries which set the field are changed
+	if got := c.HostGlobals["web"]["tunnel"]; got != "prodtunnel" {
+		t.Errorf("unexpected tunnel for web: want 'prodtunnel': got '%s'", got)
+	}
+	if got := c.HostGlobals["jump"]["tunnel"]; got != "" {
+		t.Errorf("tunnel was added to jump: got '%s'", got)
+	}
+}
+
+func TestReplaceEntries(t *testing.T) {
+	files := vipersFromString(`
+[host.basic.web]
+    address = "10.0.0.1"
+
+[host.basic.jump]
+    address = "10.0.0.2"
+
+[settings.small]
+    width = 800`)
+
+	envFiles := vipersFromStrings([]string{`
+[host.awsec2.web]
+    id = "i-12345abc"
+
+[settings.small]
+    width = 1024`})
+
+	// The names of both maps are config1, so rename the environment file
+	envFiles["env/prod/config1"] = envFiles["config1"]
+	delete(envFiles, "config1")
+
+	replaced, err := ReplaceEntries(files, envFiles)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c, err := New(replaced, true)
+	if err != nil {
+		t.Fatalf("unexpected error parsing replaced entries: %s", err)
+	}
+
+	if _, ok := c.Hosts["web"].(*hosts.EC2); !ok {
+		t.Errorf("web was not replaced by the environment entry: got %T", c.Hosts["web"])
+	}
+	if _, ok := c.Hosts["jump"]; !ok {
+		t.Errorf("jump was removed")
+	}
+	if got := c.Settings["small"].Width; got != 1024 {
+		t.Errorf("unexpected settings width: want 1024: got %d", got)
+	}
+
+	// The original files are unchanged
+	if !files["config1"].IsSet("host.basic.web") {
+		t.Errorf("entry was removed from the original files")
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TagsField is the name of the field which lists the tags of a host or template.
+const TagsField = "tags"
+
+// Group is a named set of hosts which may be connected to together. Hosts are selected by name and by tags. A host is
+// selected by Tags if it has all of them.
+type Group struct {
+	Hosts []string `mapstructure:"hosts"`
+	Tags  []string `mapstructure:"tags"`
+}
+
+// Validate returns an error if a config field is invalid.
+func (g Group) Validate() error {
+	if len(g.Hosts) == 0 && len(g.Tags) == 0 {
+		return fmt.Errorf("either hosts or tags must be set")
+	}
+
+	return nil
+}
+
+// HostHasTags returns true if the given host has all of the given tags.
+func (c *Configuration) HostHasTags(host string, tags []string) bool {
+	for _, t := range tags {
+		found := false
+		for _, hostTag := range c.HostTags[host] {
+			if hostTag == strings.ToLower(t) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HostsWithTags returns the names of the hosts which have all of the given tags, in alphabetical order.
+func (c *Configuration) HostsWithTags(tags []string) []string {
+	selected := make([]string, 0)
+
+	for _, h := range c.HostKeys() {
+		if c.HostHasTags(h, tags) {
+			selected = append(selected, h)
+		}
+	}
+
+	sort.Strings(selected)
+
+	return selected
+}
+
+// GroupExists returns true if the given group is in the configuration.
+func (c *Configuration) GroupExists(name string) bool {
+	_, ok := c.Groups[name]
+	return ok
+}
+
+// GroupHosts returns the names of the hosts in the given group in alphabetical order. Hosts which don't exist are
+// omitted.
+func (c *Configuration) GroupHosts(name string) []string {
+	g, ok := c.Groups[name]
+	if !ok {
+		return []string{}
+	}
+
+	selected := make(map[string]bool)
+
+	for _, h := range g.Hosts {
+		if c.HostExists(h) {
+			selected[h] = true
+		}
+	}
+
+	if len(g.Tags) > 0 {
+		for _, h := range c.HostsWithTags(g.Tags) {
+			selected[h] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(selected))
+	for h := range selected {
+		sorted = append(sorted, h)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// hostTags returns the value of the tags field in the data of a host entry in lower case. n is the config entry name
+// used in errors.
+func hostTags(data map[string]interface{}, n string) ([]string, error) {
+	raw, ok := data[TagsField]
+	if !ok {
+		return []string{}, nil
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, &FieldLoadError{ConfigName: n, FieldName: TagsField,
+			Message: `expected value of type array (["a", "b", "c"])`}
+	}
+
+	tags := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, &FieldLoadError{ConfigName: n, FieldName: TagsField,
+				Message: fmt.Sprintf(`array item %d: expected value of type string (["a", "b", "c"])`, i)}
+		}
+
+		tags[i] = strings.ToLower(s)
+	}
+
+	return tags, nil
+}
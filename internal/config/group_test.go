@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	c, err := New(vipersFromStrings([]string{`
+[template.basic.sql]
+    tags = ["SQL"]
+
+[host.basic.sql1]
+    extends = "sql"
+    address = "10.0.0.1"
+    tags = ["prod", "sql"]
+
+[host.basic.sql2]
+    extends = "sql"
+    address = "10.0.0.2"
+
+[host.basic.web1]
+    address = "10.0.0.3"
+    tags = ["prod"]`, `
+[group.prodsql]
+    tags = ["prod", "sql"]
+
+[group.web]
+    hosts = ["web1", "sql2"]
+    tags = ["prod", "web"]`,
+	}), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if tags := c.HostTags["sql2"]; !reflect.DeepEqual(tags, []string{"sql"}) {
+		t.Errorf("expected tags inherited by sql2 to be [sql]: got %v", tags)
+	}
+
+	if hosts := c.HostsWithTags([]string{"Prod"}); !reflect.DeepEqual(hosts, []string{"sql1", "web1"}) {
+		t.Errorf("unexpected hosts with tag prod: %v", hosts)
+	}
+
+	if hosts := c.HostsWithTags([]string{}); len(hosts) != 3 {
+		t.Errorf("expected all hosts to be returned when no tags are given: got %v", hosts)
+	}
+
+	if hosts := c.GroupHosts("prodsql"); !reflect.DeepEqual(hosts, []string{"sql1"}) {
+		t.Errorf("unexpected hosts in group prodsql: %v", hosts)
+	}
+
+	if hosts := c.GroupHosts("web"); !reflect.DeepEqual(hosts, []string{"sql2", "web1"}) {
+		t.Errorf("unexpected hosts in group web: %v", hosts)
+	}
+
+	if !c.GroupExists("web") || c.GroupExists("sql1") {
+		t.Errorf("GroupExists returned unexpected results")
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	for _, tc := range []struct {
+		config string
+		err    error
+	}{
+		{`
+[host.basic.a]
+    address = "a"
+    tags = "prod"`, &FieldLoadError{}},
+		{`
+[host.basic.a]
+    address = "a"
+    tags = [1]`, &FieldLoadError{}},
+		{`
+[group.empty]`, &InvalidConfigError{}},
+		{`
+[host.basic.a]
+    address = "a"
+[group.a]
+    hosts = ["a"]`, &DuplicateConfigNameError{}},
+		{`
+[host.basic.a]
+    address = "a"
+[group.g]
+    hosts = ["b"]`, &ReferenceError{}},
+	} {
+		_, err := New(vipersFromString(tc.config), true)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("expected error of type %T for config %s: got %v", tc.err, tc.config, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+	"github.com/danhale-git/runrdp/internal/rdp"
+)
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// EntryName converts s to a valid config entry name by making it lower case and replacing any characters which are not
+// permitted in TOML bare keys with '-'.
+func EntryName(s string) string {
+	return strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// ValidateEntryName returns an error if name can't be written as a TOML bare key, which may only contain lower case
+// letters, digits, '_' and '-'.
+func ValidateEntryName(name string) error {
+	if name == "" {
+		return fmt.Errorf("entry name must not be empty")
+	}
+
+	if invalidNameCharacters.MatchString(name) {
+		return fmt.Errorf("invalid entry name '%s': names may only contain lower case letters, digits, '_' and '-' "+
+			"(did you mean '%s'?)", name, EntryName(name))
+	}
+
+	return nil
+}
+
+// RDPFileEntries converts parsed .rdp files to host.basic entries, with any display settings and rdp.Properties in
+// settings entries. The keys of files are used as host names. Hosts with identical settings share a single settings
+// entry which is named after the first of those hosts in alphabetical order. An error describing every invalid entry
+// is returned if any of them are invalid, for example because a .rdp file has a width the settings don't allow.
+func RDPFileEntries(files map[string]*rdp.RDP) ([]Entry, error) {
+	names := make([]string, 0, len(files))
+	for k := range files {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	settings := make([]Entry, 0)
+	settingsNames := make(map[string]string) // Settings entry names by their TOML body
+	hostEntries := make([]Entry, 0)
+
+	for _, name := range names {
+		r := files[name]
+
+		host := Entry{
+			Key:    fmt.Sprintf("host.basic.%s", name),
+			Fields: map[string]interface{}{hosts.GlobalAddress.String(): r.Address},
+		}
+
+		if r.Port != "" && r.Port != rdp.DefaultPort {
+			host.Fields[hosts.GlobalPort.String()] = r.Port
+		}
+		if r.Username != "" {
+			host.Fields[hosts.GlobalUsername.String()] = r.Username
+		}
+
+		if fields := settingsFields(r); len(fields) > 0 {
+			// Compare the body of the table without the name so identical settings are shared
+			body := Entry{Fields: fields}.TOML()
+
+			settingsName, ok := settingsNames[body]
+			if !ok {
+				settingsName = name
+				settingsNames[body] = settingsName
+				settings = append(settings, Entry{Key: fmt.Sprintf("settings.%s", settingsName), Fields: fields})
+			}
+
+			host.Fields[hosts.GlobalSettings.String()] = settingsName
+		}
+
+		hostEntries = append(hostEntries, host)
+	}
+
+	entries := append(settings, hostEntries...)
+
+	problems := make([]string, 0)
+	for _, e := range entries {
+		if err := ValidateEntry(e.Key, e.Fields); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", e.Key, strings.TrimSpace(err.Error())))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid entries:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return entries, nil
+}
+
+// settingsFields returns the Settings config fields which are set in r.
+func settingsFields(r *rdp.RDP) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	if r.Width != 0 {
+		fields["width"] = r.Width
+	}
+	if r.Height != 0 {
+		fields["height"] = r.Height
+	}
+	if r.Fullscreen {
+		fields["fullscreen"] = true
+	}
+	if r.Span {
+		fields["span"] = true
+	}
+
+	values := reflect.ValueOf(r.Properties)
+	structType := values.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		name := strings.ToLower(structType.Field(i).Name)
+
+		switch v := values.Field(i); v.Kind() {
+		case reflect.String:
+			if v.String() != "" {
+				fields[name] = v.String()
+			}
+		case reflect.Ptr:
+			if !v.IsNil() {
+				fields[name] = v.Elem().Interface()
+			}
+		}
+	}
+
+	return fields
+}
+
+// CheckNewEntries returns a DuplicateConfigNameError if any of the given entries has the same name as an existing entry
+// of the same kind or as another of the given entries. Hosts and groups conflict with each other because both are
+// connected to by name. Templates only conflict with templates of the same host type.
+func (c *Configuration) CheckNewEntries(entries []Entry) error {
+	seen := make(map[string]bool)
+
+	for _, e := range entries {
+		kind, name := e.Kind(), e.Name()
+
+		id := kind + "." + name
+		switch kind {
+		case "template":
+			id = e.Key
+		case "group":
+			id = "host." + name
+		}
+
+		var exists bool
+		switch kind {
+		case "host", "group":
+			_, isHost := c.Hosts[name]
+			_, isGroup := c.Groups[name]
+			exists = isHost || isGroup
+		case "cred":
+			_, exists = c.Creds[name]
+		case "settings":
+			_, exists = c.Settings[name]
+		case "tunnel":
+			_, exists = c.Tunnels[name]
+		case "template":
+			exists = c.Templates[strings.TrimPrefix(e.Key, "template.")]
+		}
+
+		if exists || seen[id] {
+			return &DuplicateConfigNameError{Name: name}
+		}
+
+		seen[id] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/rdp"
+)
+
+func TestEntryName(t *testing.T) {
+	for in, want := range map[string]string{
+		"My Server":       "my-server",
+		"web_01.prod":     "web_01-prod",
+		"  (jump) box  ":  "jump-box",
+		"already-correct": "already-correct",
+	} {
+		if got := EntryName(in); got != want {
+			t.Errorf("unexpected name for '%s': want '%s': got '%s'", in, want, got)
+		}
+	}
+}
+
+func TestRDPFileEntries(t *testing.T) {
+	no := false
+
+	entries, err := RDPFileEntries(map[string]*rdp.RDP{
+		"web1": {Address: "10.0.0.1", Width: 800, Height: 600,
+			Properties: rdp.Properties{RedirectClipboard: &no}},
+		"web2": {Address: "10.0.0.2", Port: "3390", Username: "admin", Width: 800, Height: 600,
+			Properties: rdp.Properties{RedirectClipboard: &no}},
+		"db1": {Address: "10.0.0.3", Port: rdp.DefaultPort},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Identical settings are shared, hosts without settings don't have a settings entry
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+
+	want := []string{"settings.web1", "host.basic.db1", "host.basic.web1", "host.basic.web2"}
+	if len(keys) != len(want) {
+		t.Fatalf("unexpected entries: want %s: got %s", want, keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("unexpected entries: want %s: got %s", want, keys)
+		}
+	}
+
+	// The generated TOML is a valid configuration
+	c, err := New(vipersFromString(EntriesTOML(entries)), true)
+	if err != nil {
+		t.Fatalf("unexpected error parsing imported entries: %s", err)
+	}
+
+	if got := c.HostGlobals["web2"]["settings"]; got != "web1" {
+		t.Errorf("web2 does not use the shared settings: got '%s'", got)
+	}
+	if got := c.HostGlobals["web2"]["port"]; got != "3390" {
+		t.Errorf("unexpected port for web2: %s", got)
+	}
+	if got := c.HostGlobals["db1"]["port"]; got != "" {
+		t.Errorf("default port was written for db1: %s", got)
+	}
+
+	s := c.Settings["web1"]
+	if s.Width != 800 || s.Height != 600 || s.RedirectClipboard == nil || *s.RedirectClipboard {
+		t.Errorf("unexpected imported settings: %+v", s)
+	}
+
+	// Importing the same entries again is refused
+	if err := c.CheckNewEntries(entries); !errors.Is(err, &DuplicateConfigNameError{}) {
+		t.Errorf("unexpected error checking duplicate entries: expected DuplicateConfigNameError: got %v", err)
+	}
+}
+
+func TestRDPFileEntries_Invalid(t *testing.T) {
+	bpp := 8
+
+	_, err := RDPFileEntries(map[string]*rdp.RDP{
+		"small": {Address: "10.0.0.1", Width: 100},
+		"depth": {Address: "10.0.0.2", Properties: rdp.Properties{SessionBPP: &bpp}},
+		"":      {Address: "10.0.0.3"},
+	})
+	if err == nil {
+		t.Fatalf("no error returned for invalid entries")
+	}
+
+	for _, want := range []string{"settings.small", "width", "settings.depth", "sessionbpp", "host.basic.:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s: %s", want, err)
+		}
+	}
+}
+
+func TestCheckNewEntries_HostsAndGroups(t *testing.T) {
+	c, err := New(vipersFromString(`
+[host.basic.web]
+    address = "10.0.0.1"
+
+[group.all]
+    hosts = ["web"]`), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, entries := range [][]Entry{
+		{{Key: "host.basic.all"}},
+		{{Key: "group.web"}},
+		{{Key: "host.basic.new"}, {Key: "group.new"}},
+	} {
+		if err := c.CheckNewEntries(entries); !errors.Is(err, &DuplicateConfigNameError{}) {
+			t.Errorf("expected DuplicateConfigNameError adding %v: got %v", entries, err)
+		}
+	}
+}
+
+func TestCheckNewEntries_Templates(t *testing.T) {
+	c, err := New(vipersFromString(`
+[template.basic.sql]
+    port = 1433`), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := c.CheckNewEntries([]Entry{{Key: "template.basic.sql"}}); !errors.Is(err, &DuplicateConfigNameError{}) {
+		t.Errorf("expected DuplicateConfigNameError adding an existing template: got %v", err)
+	}
+
+	if err := c.CheckNewEntries([]Entry{{Key: "template.awsec2.sql"}, {Key: "host.basic.sql"}}); err != nil {
+		t.Errorf("unexpected error adding entries with the name of a template of another type: %s", err)
+	}
+}
+
+func TestEntry_TOML(t *testing.T) {
+	got := Entry{Key: "host.basic.test", Fields: map[string]interface{}{
+		"address": `quote " and \ backslash`,
+		"private": true,
+		"width":   800,
+		"list":    []string{"a", "b"},
+	}}.TOML()
+
+	want := `[host.basic.test]
+    address = "quote \" and \\ backslash"
+    list = ["a", "b"]
+    private = true
+    width = 800
+`
+
+	if got != want {
+		t.Errorf("unexpected toml:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
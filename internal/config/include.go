@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pelletier/go-toml"
+)
+
+// IncludeKey is the name of the top level field which lists other config files to load. Each item is a file, directory
+// or glob pattern. Relative paths are relative to the directory of the file which includes them.
+const IncludeKey = "include"
+
+// FindConfigFiles returns the paths of the files in directory with the given extension, and in its sub-directories if
+// recursive is true. Hidden sub-directories and the environment directory (see EnvDirectory) are skipped. Files
+// included by those files are added after them, recursively. Each file is returned once, so includes which form a
+// cycle are only followed once.
+//
+// The second value returned maps the path of each included file to the path of the file which first included it.
+func FindConfigFiles(directory, extension string, recursive bool) ([]string, map[string]string, error) {
+	paths, err := filesInDirectory(directory, extension, recursive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	includedBy := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		seen[filepath.Clean(p)] = true
+	}
+
+	// paths grows as included files are found
+	for i := 0; i < len(paths); i++ {
+		included, err := includes(paths[i], extension, recursive)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, inc := range included {
+			if seen[filepath.Clean(inc)] {
+				continue
+			}
+
+			seen[filepath.Clean(inc)] = true
+			includedBy[inc] = paths[i]
+			paths = append(paths, inc)
+		}
+	}
+
+	return paths, includedBy, nil
+}
+
+// filesInDirectory returns the paths of all files in directory with the given extension in alphabetical order.
+func filesInDirectory(directory, extension string, recursive bool) ([]string, error) {
+	if !recursive {
+		infos, err := ioutil.ReadDir(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		paths := make([]string, 0)
+		for _, f := range infos {
+			if !f.IsDir() && filepath.Ext(f.Name()) == extension {
+				paths = append(paths, filepath.Join(directory, f.Name()))
+			}
+		}
+
+		return paths, nil
+	}
+
+	paths := make([]string, 0)
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != directory && strings.HasPrefix(info.Name(), ".") ||
+				path == filepath.Join(directory, EnvDirectory) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if filepath.Ext(path) == extension {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+
+	return paths, err
+}
+
+// includes returns the paths of the files included by the config file at path, in the order they are listed.
+// Directories are replaced with the files they contain. Files which can't be parsed are ignored here so their
+// problems are reported when they are read.
+func includes(path, extension string, recursive bool) ([]string, error) {
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	patterns, err := includePatterns(tree.Get(IncludeKey))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	paths := make([]string, 0)
+
+	for _, pattern := range patterns {
+		expanded, err := homedir.Expand(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: including '%s': %w", path, pattern, err)
+		}
+
+		if !filepath.IsAbs(expanded) {
+			expanded = filepath.Join(filepath.Dir(path), expanded)
+		}
+
+		matches, err := filepath.Glob(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("%s: including '%s': %w", path, pattern, err)
+		}
+
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("%s: included file '%s' does not exist", path, expanded)
+		}
+
+		sort.Strings(matches)
+
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+
+			if !info.IsDir() {
+				paths = append(paths, m)
+				continue
+			}
+
+			files, err := filesInDirectory(m, extension, recursive)
+			if err != nil {
+				return nil, err
+			}
+
+			paths = append(paths, files...)
+		}
+	}
+
+	return paths, nil
+}
+
+// includePatterns returns the value of an include field, which may be a string or an array of strings.
+func includePatterns(v interface{}) ([]string, error) {
+	switch t := v.(type) {
+	case nil:
+		return []string{}, nil
+	case string:
+		return []string{t}, nil
+	case []interface{}:
+		patterns := make([]string, len(t))
+		for i, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("'%s' item %d: expected value of type string", IncludeKey, i)
+			}
+
+			patterns[i] = s
+		}
+
+		return patterns, nil
+	}
+
+	return nil, fmt.Errorf("'%s' must be a string or an array of strings", IncludeKey)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindConfigFiles(t *testing.T) {
+	root := t.TempDir()
+	shared := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		filepath.Join(root, "config.toml"):         `include = ["sub/extra.toml", "` + filepath.ToSlash(shared) + `"]`,
+		filepath.Join(root, "notes.txt"):           ``,
+		filepath.Join(root, "sub", "extra.toml"):   `include = "../config.toml"`,
+		filepath.Join(root, "sub", "nested.toml"):  ``,
+		filepath.Join(root, ".git", "config.toml"): ``,
+		filepath.Join(shared, "team.toml"):         `include = ["*.toml"]`,
+		filepath.Join(shared, "team-hosts.toml"):   ``,
+		filepath.Join(shared, "ignored", "a.toml"): ``,
+	})
+
+	paths, includedBy, err := FindConfigFiles(root, ".toml", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{
+		filepath.Join(root, "config.toml"),
+		filepath.Join(root, "sub", "extra.toml"),
+		filepath.Join(shared, "team-hosts.toml"),
+		filepath.Join(shared, "team.toml"),
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected paths:\nwant:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(paths, "\n"))
+	}
+
+	if got := includedBy[filepath.Join(shared, "team.toml")]; got != filepath.Join(root, "config.toml") {
+		t.Errorf("unexpected file including team.toml: %s", got)
+	}
+	if _, ok := includedBy[filepath.Join(root, "config.toml")]; ok {
+		t.Errorf("config.toml was reported as included")
+	}
+
+	paths, _, err = FindConfigFiles(root, ".toml", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want = []string{
+		filepath.Join(root, "config.toml"),
+		filepath.Join(root, "sub", "extra.toml"),
+		filepath.Join(root, "sub", "nested.toml"),
+		filepath.Join(shared, "ignored", "a.toml"),
+		filepath.Join(shared, "team-hosts.toml"),
+		filepath.Join(shared, "team.toml"),
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected recursive paths:\nwant:\n%s\ngot:\n%s", strings.Join(want, "\n"),
+			strings.Join(paths, "\n"))
+	}
+
+	writeTestFiles(t, map[string]string{filepath.Join(root, "config.toml"): `include = ["missing.toml"]`})
+	if _, _, err := FindConfigFiles(root, ".toml", false); err == nil {
+		t.Errorf("no error returned when an included file doesn't exist")
+	}
+}
+
+func writeTestFiles(t *testing.T, files map[string]string) {
+	for path, body := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+	"github.com/spf13/viper"
+)
+
+// ExtendsField is the name of the field used by a host or template to inherit the fields of another host or template
+// of the same type.
+const ExtendsField = "extends"
+
+// hostEntries returns the data of all host entries by host type and name. The fields of any host or template a host
+// extends are merged into its data, with the host's own values taking precedence.
+func hostEntries(vipers map[string]*viper.Viper, vars map[string]string) (map[string]map[string]rawEntry, error) {
+	hostTypes := make(map[string]string) // Host type by host name
+	all := make(map[string]map[string]rawEntry)
+	templates := make(map[string]map[string]rawEntry)
+
+	for t := range hosts.Map {
+		var err error
+
+		if all[t], err = rawEntries(vipers, vars, fmt.Sprintf("host.%s", t)); err != nil {
+			return nil, err
+		}
+
+		if templates[t], err = rawEntries(vipers, vars, fmt.Sprintf("template.%s", t)); err != nil {
+			return nil, err
+		}
+
+		for name := range all[t] {
+			if _, ok := hostTypes[name]; ok {
+				return nil, &DuplicateConfigNameError{Name: name}
+			}
+
+			hostTypes[name] = t
+		}
+	}
+
+	resolved := make(map[string]map[string]rawEntry)
+	for t := range hosts.Map {
+		resolved[t] = make(map[string]rawEntry)
+	}
+
+	for name, t := range hostTypes {
+		data, err := inherit(t, fmt.Sprintf("host.%s.%s", t, name), all[t][name], all, hostTypes, templates, []string{})
+		if err != nil {
+			return nil, err
+		}
+
+		resolved[t][name] = rawEntry{config: all[t][name].config, data: data}
+	}
+
+	return resolved, nil
+}
+
+// inherit returns the data of entry merged with the data of the entry it extends, recursively. Templates of the same
+// type are looked up before hosts. chain is the keys of the entries which have already been visited and is used to
+// detect cycles, so a host may extend a template with the same name.
+func inherit(hostType, key string, entry rawEntry, all map[string]map[string]rawEntry, hostTypes map[string]string,
+	templates map[string]map[string]rawEntry, chain []string) (map[string]interface{}, error) {
+	chain = append(chain, key)
+	name := Entry{Key: key}.Name()
+
+	data := make(map[string]interface{}, len(entry.data))
+	for k, v := range entry.data {
+		if k != ExtendsField {
+			data[k] = v
+		}
+	}
+
+	raw, ok := entry.data[ExtendsField]
+	if !ok {
+		return data, nil
+	}
+
+	parentName, ok := raw.(string)
+	if !ok {
+		return nil, &FieldLoadError{ConfigName: name, FieldName: ExtendsField, Message: "expected value of type string"}
+	}
+
+	parentKey := fmt.Sprintf("template.%s.%s", hostType, parentName)
+
+	parent, ok := templates[hostType][parentName]
+	if !ok {
+		parentType, exists := hostTypes[parentName]
+		if !exists {
+			return nil, &InvalidConfigError{Reason: fmt.Errorf("%s extends '%s' which is not a host or %s template",
+				name, parentName, hostType)}
+		}
+
+		if parentType != hostType {
+			return nil, &InvalidConfigError{Reason: fmt.Errorf("%s host %s can't extend %s host %s",
+				hostType, name, parentType, parentName)}
+		}
+
+		parent = all[hostType][parentName]
+		parentKey = fmt.Sprintf("host.%s.%s", hostType, parentName)
+	}
+
+	for _, c := range chain {
+		if c == parentKey {
+			return nil, &InvalidConfigError{Reason: fmt.Errorf("'%s' cycle: %s -> %s",
+				ExtendsField, strings.Join(chain, " -> "), parentKey)}
+		}
+	}
+
+	merged, err := inherit(hostType, parentKey, parent, all, hostTypes, templates, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range data {
+		merged[k] = v
+	}
+
+	return merged, nil
+}
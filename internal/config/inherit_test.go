@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+)
+
+func TestHostInheritance(t *testing.T) {
+	c, err := New(vipersFromStrings([]string{`
+[template.awsec2.base]
+    profile = "dev"
+    region = "eu-west-2"
+    private = true
+    cred = "mycred"
+
+[template.awsec2.tunnelled]
+    extends = "base"
+    tunnel = "mytunnel"
+    region = "eu-west-1"`, `
+[host.awsec2.web1]
+    extends = "tunnelled"
+    id = "i-12345abc"
+
+[host.awsec2.web2]
+    extends = "web1"
+    id = "i-67890def"
+    private = false
+    tunnel = ""`,
+	}), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, ok := c.Hosts["base"]; ok {
+		t.Errorf("template was loaded as a host")
+	}
+
+	web1 := c.Hosts["web1"].(*hosts.EC2)
+	if web1.ID != "i-12345abc" || web1.Profile != "dev" || web1.Region != "eu-west-1" || !web1.Private {
+		t.Errorf("unexpected fields inherited by web1: %+v", web1)
+	}
+	if g := c.HostGlobals["web1"]; g["cred"] != "mycred" || g["tunnel"] != "mytunnel" {
+		t.Errorf("unexpected global fields inherited by web1: %v", g)
+	}
+
+	// Local values override inherited values, including empty values
+	web2 := c.Hosts["web2"].(*hosts.EC2)
+	if web2.ID != "i-67890def" || web2.Region != "eu-west-1" || web2.Private {
+		t.Errorf("unexpected fields inherited by web2: %+v", web2)
+	}
+	if g := c.HostGlobals["web2"]; g["cred"] != "mycred" || g["tunnel"] != "" {
+		t.Errorf("unexpected global fields inherited by web2: %v", g)
+	}
+
+	// A host may extend a template with the same name
+	c, err = New(vipersFromString(`
+[template.awsec2.web]
+    region = "eu-west-2"
+
+[host.awsec2.web]
+    extends = "web"
+    id = "i-12345abc"`), false)
+	if err != nil {
+		t.Fatalf("unexpected error extending a template with the same name: %s", err)
+	}
+
+	if web := c.Hosts["web"].(*hosts.EC2); web.Region != "eu-west-2" {
+		t.Errorf("unexpected fields inherited by web: %+v", web)
+	}
+
+	for _, cfg := range []string{`
+[template.awsec2.a]
+    extends = "a"
+[host.awsec2.a]
+    extends = "a"`, `
+[host.awsec2.a]
+    extends = "b"
+[host.awsec2.b]
+    extends = "a"`, `
+[host.awsec2.a]
+    extends = "a"`, `
+[host.awsec2.a]
+    extends = "doesnotexist"`, `
+[host.basic.a]
+    address = "10.0.0.1"
+[host.awsec2.b]
+    extends = "a"`,
+	} {
+		if _, err := New(vipersFromString(cfg), false); !errors.Is(err, &InvalidConfigError{}) {
+			t.Errorf("unexpected error: expected InvalidConfigError: got %v: config: %s", err, cfg)
+		}
+	}
+
+	if _, err := New(vipersFromString(`
+[host.awsec2.a]
+    extends = 1`), false); !errors.Is(err, &FieldLoadError{}) {
+		t.Errorf("unexpected error: expected FieldLoadError: got %v", err)
+	}
+}
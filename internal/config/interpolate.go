@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// VarsKey is the name of the top level table which defines variables for use in ${var:name} references.
+const VarsKey = "vars"
+
+// interpolationPattern matches an escaped '$${' or a ${source:name} or ${source:name:-default} reference.
+var interpolationPattern = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// interpolate replaces references in s with their values. ${env:NAME} is replaced with the value of an environment
+// variable and ${var:name} with a variable from vars. A default which is used if the value is undefined or empty may be
+// given with ${env:NAME:-default}. '$${' is replaced with a literal '${'. If vars is nil, ${var:name} references are
+// not permitted.
+func interpolate(s string, vars map[string]string) (string, error) {
+	var err error
+
+	result := interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		if err != nil {
+			return match
+		}
+
+		var value string
+		value, err = resolveReference(interpolationPattern.FindStringSubmatch(match)[1], vars)
+
+		return value
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// resolveReference returns the value of the body of a ${...} reference.
+func resolveReference(ref string, vars map[string]string) (string, error) {
+	parts := strings.SplitN(ref, ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid reference '${%s}': expected ${env:NAME} or ${var:name}", ref)
+	}
+
+	source, name := parts[0], parts[1]
+
+	dfault, hasDefault := "", false
+	if i := strings.Index(name, ":-"); i >= 0 {
+		name, dfault, hasDefault = name[:i], name[i+2:], true
+	}
+
+	var value string
+
+	switch source {
+	case "env":
+		value = os.Getenv(name)
+		if value == "" && !hasDefault {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+	case "var":
+		if vars == nil {
+			return "", fmt.Errorf("'${%s}': variables may not refer to other variables", ref)
+		}
+
+		var ok bool
+		value, ok = vars[strings.ToLower(name)]
+		if !ok && !hasDefault {
+			return "", fmt.Errorf("variable %s is not defined in [%s]", name, VarsKey)
+		}
+	default:
+		return "", fmt.Errorf("invalid reference '${%s}': '%s' is not a source, must be env or var", ref, source)
+	}
+
+	if value == "" {
+		value = dfault
+	}
+
+	return value, nil
+}
+
+// interpolateFields returns a copy of data with references in string values and string array items replaced by
+// interpolate. n is the config entry name used in errors.
+func interpolateFields(data map[string]interface{}, vars map[string]string, n string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(data))
+
+	for k, v := range data {
+		switch t := v.(type) {
+		case string:
+			s, err := interpolate(t, vars)
+			if err != nil {
+				return nil, &FieldLoadError{ConfigName: n, FieldName: k, Message: err.Error()}
+			}
+
+			result[k] = s
+		case []interface{}:
+			items := make([]interface{}, len(t))
+			for i, item := range t {
+				items[i] = item
+
+				if s, ok := item.(string); ok {
+					var err error
+					if items[i], err = interpolate(s, vars); err != nil {
+						return nil, &FieldLoadError{ConfigName: n, FieldName: k,
+							Message: fmt.Sprintf("array item %d: %s", i, err)}
+					}
+				}
+			}
+
+			result[k] = items
+		default:
+			result[k] = v
+		}
+	}
+
+	return result, nil
+}
+
+// parseVars adds the variables defined in the vars table of all vipers to m. Variable values may refer to environment
+// variables but not to other variables.
+func parseVars(vipers map[string]*viper.Viper, m map[string]string) error {
+	for _, v := range vipers {
+		for name, raw := range v.GetStringMap(VarsKey) {
+			value, err := varValue(name, raw)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := m[name]; ok {
+				return &DuplicateConfigNameError{Name: fmt.Sprintf("%s.%s", VarsKey, name)}
+			}
+
+			m[name] = value
+		}
+	}
+
+	return nil
+}
+
+// varValue returns the interpolated string value of a variable, which may be a string, integer or bool.
+func varValue(name string, raw interface{}) (string, error) {
+	switch raw.(type) {
+	case string, int64, bool:
+	default:
+		return "", &FieldLoadError{ConfigName: VarsKey, FieldName: name,
+			Message: "expected value of type string, integer or bool"}
+	}
+
+	value, err := interpolate(fmt.Sprint(raw), nil)
+	if err != nil {
+		return "", &FieldLoadError{ConfigName: VarsKey, FieldName: name, Message: err.Error()}
+	}
+
+	return value, nil
+}
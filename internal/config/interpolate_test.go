@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+)
+
+func TestInterpolate(t *testing.T) {
+	if err := os.Setenv("RUNRDP_TEST_REGION", "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Unsetenv("RUNRDP_TEST_REGION") }()
+
+	vars := map[string]string{"account": "dev", "empty": ""}
+
+	for in, want := range map[string]string{
+		"${env:RUNRDP_TEST_REGION}":                "eu-west-1",
+		"${env:RUNRDP_TEST_UNSET:-eu-west-2}":      "eu-west-2",
+		"${env:RUNRDP_TEST_REGION:-eu-west-2}":     "eu-west-1",
+		"${var:account}-profile":                   "dev-profile",
+		"${var:ACCOUNT}":                           "dev",
+		"${var:empty:-default}":                    "default",
+		"${var:undefined:-}":                       "",
+		"$${env:RUNRDP_TEST_REGION}":               "${env:RUNRDP_TEST_REGION}",
+		"no references":                            "no references",
+		"${var:account}/${env:RUNRDP_TEST_REGION}": "dev/eu-west-1",
+	} {
+		got, err := interpolate(in, vars)
+		if err != nil {
+			t.Errorf("unexpected error interpolating '%s': %s", in, err)
+		} else if got != want {
+			t.Errorf("unexpected value for '%s': want '%s': got '%s'", in, want, got)
+		}
+	}
+
+	for _, in := range []string{
+		"${env:RUNRDP_TEST_UNSET}",
+		"${var:undefined}",
+		"${instance_id}",
+		"${file:path}",
+	} {
+		if _, err := interpolate(in, vars); err == nil {
+			t.Errorf("no error returned interpolating '%s'", in)
+		}
+	}
+
+	if _, err := interpolate("${var:account}", nil); err == nil {
+		t.Errorf("no error returned for a variable which refers to a variable")
+	}
+}
+
+func TestNew_Interpolation(t *testing.T) {
+	if err := os.Setenv("RUNRDP_TEST_PROFILE", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Unsetenv("RUNRDP_TEST_PROFILE") }()
+
+	c, err := New(vipersFromStrings([]string{`
+[vars]
+    region = "${env:RUNRDP_TEST_UNSET:-eu-west-2}"
+    user = "admin"`, `
+[host.awsec2.web]
+    id = "i-12345abc"
+    profile = "${env:RUNRDP_TEST_PROFILE}"
+    region = "${var:region}"
+    username = "${var:user}"`,
+	}), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	web := c.Hosts["web"].(*hosts.EC2)
+	if web.Profile != "prod" || web.Region != "eu-west-2" {
+		t.Errorf("unexpected interpolated fields: %+v", web)
+	}
+
+	if got := c.HostGlobals["web"]["username"]; got != "admin" {
+		t.Errorf("unexpected interpolated global field: want 'admin': got '%s'", got)
+	}
+
+	_, err = New(vipersFromString(`
+[host.awsec2.web]
+    region = "${var:undefined}"`), false)
+
+	var fieldErr *FieldLoadError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("unexpected error for undefined variable: expected FieldLoadError: got %v", err)
+	}
+	if fieldErr.FieldName != "region" {
+		t.Errorf("unexpected field name in error: want 'region': got '%s'", fieldErr.FieldName)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -13,28 +14,64 @@ import (
 )
 
 func parseConfiguration(v map[string]*viper.Viper, c *Configuration) error {
-	if err := parseHosts(v, c.Hosts, c.HostGlobals); err != nil {
+	if err := parseVars(v, c.Vars); err != nil {
+		return fmt.Errorf("parsing vars: %w", err)
+	}
+
+	if err := parseHosts(v, c.Vars, c.Hosts, c.HostGlobals, c.HostTags); err != nil {
 		return fmt.Errorf("parsing hosts: %w", err)
 	}
 
-	if err := parseCreds(v, c.Creds); err != nil {
+	if err := parseInheritance(v, c.Vars, c.Templates, c.Extends); err != nil {
+		return fmt.Errorf("parsing templates: %w", err)
+	}
+
+	if err := parseCreds(v, c.Vars, c.Creds); err != nil {
 		return fmt.Errorf("parsing creds: %w", err)
 	}
 
-	if err := parseSettings(v, c.Settings); err != nil {
+	if err := parseSettings(v, c.Vars, c.Settings); err != nil {
 		return fmt.Errorf("parsing settings: %w", err)
 	}
 
-	if err := parseTunnels(v, c.Tunnels); err != nil {
+	if err := parseTunnels(v, c.Vars, c.Tunnels); err != nil {
 		return fmt.Errorf("parsing tunnels: %w", err)
 	}
 
+	if err := parseGroups(v, c.Vars, c.Groups); err != nil {
+		return fmt.Errorf("parsing groups: %w", err)
+	}
+
+	// Groups are connected to by name in the same way as hosts
+	for k := range c.Groups {
+		if c.HostExists(k) {
+			return &DuplicateConfigNameError{Name: k}
+		}
+	}
+
 	return nil
 }
 
-func parseHosts(v map[string]*viper.Viper, hm map[string]Host, gm map[string]map[string]string) error {
+func parseHosts(v map[string]*viper.Viper, vars map[string]string, hm map[string]Host,
+	gm map[string]map[string]string, tm map[string][]string) error {
+	entries, err := hostEntries(v, vars)
+	if err != nil {
+		return err
+	}
+
 	for key, typeFunc := range hosts.Map {
-		h, err := parse(v, fmt.Sprintf("host.%s", key), typeFunc)
+		// Tags are removed before the fields are set because they are not a field of the host type
+		for k, e := range entries[key] {
+			tags, err := hostTags(e.data, fmt.Sprintf("host.%s.%s", key, k))
+			if err != nil {
+				return err
+			}
+
+			tm[k] = tags
+			delete(e.data, TagsField)
+		}
+
+		h, err := parseEntries(entries[key], fmt.Sprintf("host.%s", key), typeFunc)
 		if err != nil {
 			return err
 		}
@@ -50,21 +87,22 @@ func parseHosts(v map[string]*viper.Viper, hm map[string]Host, gm map[string]map
 			}
 		}
 
-		g, err := parseGlobals(v, fmt.Sprintf("host.%s", key))
-		if err != nil {
-			return fmt.Errorf("parsing global fields: %s", err)
-		}
-		for k, v := range g {
-			gm[k] = v.(map[string]string)
+		for k, e := range entries[key] {
+			g, err := getGlobals(e.data)
+			if err != nil {
+				return fmt.Errorf("parsing global fields: %s", err)
+			}
+
+			gm[k] = g
 		}
 	}
 
 	return nil
 }
 
-func parseCreds(v map[string]*viper.Viper, m map[string]Cred) error {
+func parseCreds(v map[string]*viper.Viper, vars map[string]string, m map[string]Cred) error {
 	for key, typeFunc := range creds.Map {
-		cr, err := parse(v, fmt.Sprintf("cred.%s", key), typeFunc)
+		cr, err := parse(v, vars, fmt.Sprintf("cred.%s", key), typeFunc)
 		if err != nil {
 			return err
 		}
@@ -75,6 +113,10 @@ func parseCreds(v map[string]*viper.Viper, m map[string]Cred) error {
 			}
 			m[k] = v.(Cred)
 
+			if n, ok := m[k].(creds.Named); ok {
+				n.SetName(k)
+			}
+
 			if err := m[k].Validate(); err != nil {
 				return &InvalidConfigError{Reason: fmt.Errorf("%s configuration is invalid: %w", k, err)}
 			}
@@ -84,8 +126,8 @@ func parseCreds(v map[string]*viper.Viper, m map[string]Cred) error {
 	return nil
 }
 
-func parseSettings(v map[string]*viper.Viper, m map[string]Settings) error {
-	s, err := parse(v, "settings", func() interface{} { return &Settings{} })
+func parseSettings(v map[string]*viper.Viper, vars map[string]string, m map[string]Settings) error {
+	s, err := parse(v, vars, "settings", func() interface{} { return &Settings{} })
 	if err != nil {
 		return err
 	}
@@ -104,8 +146,35 @@ func parseSettings(v map[string]*viper.Viper, m map[string]Settings) error {
 	return nil
 }
 
-func parseTunnels(v map[string]*viper.Viper, m map[string]Tunnel) error {
-	t, err := parse(v, "tunnel", func() interface{} { return &Tunnel{} })
+// parseInheritance adds the host type and name of every template to tm and the name of the entry each host and
+// template extends to em by entry key, for example host.basic.web. The fields of templates and the entries they extend
+// are parsed as part of the hosts which extend them (see hostEntries), so only their names are recorded here.
+func parseInheritance(v map[string]*viper.Viper, vars map[string]string, tm map[string]bool,
+	em map[string]string) error {
+	for t := range hosts.Map {
+		for _, kind := range []string{"host", "template"} {
+			entries, err := rawEntries(v, vars, fmt.Sprintf("%s.%s", kind, t))
+			if err != nil {
+				return err
+			}
+
+			for name, e := range entries {
+				if kind == "template" {
+					tm[fmt.Sprintf("%s.%s", t, name)] = true
+				}
+
+				if parent, ok := e.data[ExtendsField].(string); ok {
+					em[fmt.Sprintf("%s.%s.%s", kind, t, name)] = parent
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseTunnels(v map[string]*viper.Viper, vars map[string]string, m map[string]Tunnel) error {
+	t, err := parse(v, vars, "tunnel", func() interface{} { return &Tunnel{} })
 	if err != nil {
 		return err
 	}
@@ -124,8 +193,16 @@ func parseTunnels(v map[string]*viper.Viper, m map[string]Tunnel) error {
 	return nil
 }
 
-func parse(vipers map[string]*viper.Viper, key string, typeFunc func() interface{}) (map[string]interface{}, error) {
-	parsed := make(map[string]interface{})
+// rawEntry is the unparsed data of a single config entry.
+type rawEntry struct {
+	config string // Name of the config the entry was read from
+	data   map[string]interface{}
+}
+
+// rawEntries returns the data of every entry under key in all vipers, by entry name. References to variables in string
+// values are replaced using vars (see interpolate).
+func rawEntries(vipers map[string]*viper.Viper, vars map[string]string, key string) (map[string]rawEntry, error) {
+	entries := make(map[string]rawEntry)
 
 	for cfgName, v := range vipers {
 		if !v.IsSet(key) {
@@ -135,51 +212,108 @@ func parse(vipers map[string]*viper.Viper, key string, typeFunc func() interface
 		all := v.Get(key).(map[string]interface{})
 
 		for name, raw := range all {
-			h := typeFunc()
-			value := reflect.ValueOf(h).Elem()
-			if err := setFields(value, raw.(map[string]interface{})); err != nil {
+			data, err := interpolateFields(raw.(map[string]interface{}), vars, fmt.Sprintf("%s.%s", key, name))
+			if err != nil {
 				return nil, fmt.Errorf("reading '%s' fields for %s in '%s': %w", key, name, cfgName, err)
 			}
 
-			parsed[name] = h
+			if existing, ok := entries[name]; ok {
+				files := []string{existing.config, cfgName}
+				sort.Strings(files)
+
+				return nil, &DuplicateConfigNameError{Name: fmt.Sprintf("%s.%s", key, name), Files: files}
+			}
+
+			entries[name] = rawEntry{config: cfgName, data: data}
 		}
 	}
 
-	return parsed, nil
+	return entries, nil
 }
 
-func parseGlobals(vipers map[string]*viper.Viper, key string) (map[string]interface{}, error) {
-	parsed := make(map[string]interface{})
+func parseGroups(v map[string]*viper.Viper, vars map[string]string, m map[string]Group) error {
+	g, err := parse(v, vars, "group", func() interface{} { return &Group{} })
+	if err != nil {
+		return err
+	}
 
-	for _, v := range vipers {
-		if !v.IsSet(key) {
-			continue
+	for k, v := range g {
+		if _, ok := m[k]; ok {
+			return &DuplicateConfigNameError{Name: k}
 		}
+		m[k] = *(v.(*Group))
 
-		all := v.Get(key).(map[string]interface{})
+		if err := m[k].Validate(); err != nil {
+			return &InvalidConfigError{Reason: fmt.Errorf("%s configuration is invalid: %w", k, err)}
+		}
+	}
 
-		for name, raw := range all {
-			g, err := getGlobals(raw.(map[string]interface{}))
-			if err != nil {
-				return nil, err
-			}
+	return nil
+}
+
+func parse(vipers map[string]*viper.Viper, vars map[string]string, key string,
+	typeFunc func() interface{}) (map[string]interface{}, error) {
+	entries, err := rawEntries(vipers, vars, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseEntries(entries, key, typeFunc)
+}
+
+func parseEntries(entries map[string]rawEntry, key string, typeFunc func() interface{}) (map[string]interface{}, error) {
+	parsed := make(map[string]interface{})
 
-			parsed[name] = g
+	for name, e := range entries {
+		h := typeFunc()
+		value := reflect.ValueOf(h).Elem()
+		if err := setFields(value, e.data); err != nil {
+			return nil, fmt.Errorf("reading '%s' fields for %s in '%s': %w", key, name, e.config, err)
 		}
+
+		parsed[name] = h
 	}
 
 	return parsed, nil
 }
 
 // setFields uses reflection to populate the fields of a struct from values in a map. Any values not present in the map
-// will be left empty in the struct.
+// will be left empty in the struct. The fields of embedded structs are populated as if they belonged to the outer struct.
 func setFields(values reflect.Value, data map[string]interface{}) error {
 	structType := values.Type()
 
 	// Map fields to their lower case names
 	valueMap := make(map[string]reflect.Value)
+	mapFields(values, valueMap)
+
+	// Iterate over all the values given in the config entry
+	for k, v := range data {
+		if hosts.FieldNameIsGlobal(k) {
+			continue
+		}
+
+		// Check if the config entry has a corresponding field in the struct
+		_, exists := valueMap[k]
+		if !exists {
+			return fmt.Errorf("config key %s is invalid for type %s", k, structType.Name())
+		}
+
+		if err := setValue(valueMap[k], v, strings.ToLower(structType.Name()), k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapFields adds the exported fields of a struct to valueMap by their lower case names. Fields of embedded structs are
+// added as if they belonged to the outer struct.
+func mapFields(values reflect.Value, valueMap map[string]reflect.Value) {
+	structType := values.Type()
+
 	for i := 0; i < structType.NumField(); i++ {
-		rawFieldName := structType.Field(i).Name
+		field := structType.Field(i)
+		rawFieldName := field.Name
 
 		// Ignore unexported fields
 		if unicode.IsLower(rune(rawFieldName[0])) {
@@ -187,6 +321,12 @@ func setFields(values reflect.Value, data map[string]interface{}) error {
 		}
 
 		v := values.Field(i)
+
+		if field.Anonymous && v.Kind() == reflect.Struct {
+			mapFields(v, valueMap)
+			continue
+		}
+
 		fieldName := strings.ToLower(rawFieldName)
 
 		valueMap[fieldName] = v
@@ -196,88 +336,84 @@ func setFields(values reflect.Value, data map[string]interface{}) error {
 				structType.Name(), fieldName))
 		}
 	}
+}
 
-	// Iterate over all the values given in the config entry
-	for k, v := range data {
-		if hosts.FieldNameIsGlobal(k) {
-			continue
+// setValue validates a config value and assigns it to a struct field. n and k are the config type and key names used
+// in errors.
+func setValue(value reflect.Value, v interface{}, n, k string) error {
+	switch value.Kind() {
+	case reflect.Bool:
+		dt, ok := v.(bool)
+		if !ok {
+			return &FieldLoadError{ConfigName: n, FieldName: k,
+				Message: "expected value of type bool"}
 		}
 
-		// Check if the config entry has a corresponding field in the struct
-		_, exists := valueMap[k]
-		if !exists {
-			return fmt.Errorf("config key %s is invalid for type %s", k, structType.Name())
+		value.SetBool(dt)
+
+	case reflect.Int:
+		dt, ok := v.(int64)
+		if !ok {
+			return &FieldLoadError{ConfigName: n, FieldName: k,
+				Message: "expected value of type integer"}
 		}
 
-		value := valueMap[k]
-		n := strings.ToLower(structType.Name())
+		value.SetInt(dt)
 
-		// Validate the config value and assign it to the struct field
-		switch value.Kind() {
-		case reflect.Bool:
-			dt, ok := v.(bool)
-			if !ok {
-				return &FieldLoadError{ConfigName: n, FieldName: k,
-					Message: "expected value of type bool"}
-			}
+	case reflect.String:
+		dt, ok := v.(string)
+		if !ok {
+			return &FieldLoadError{ConfigName: n, FieldName: k,
+				Message: "expected value of type string"}
+		}
 
-			value.SetBool(dt)
+		value.SetString(dt)
 
-		case reflect.Int:
-			dt, ok := v.(int64)
-			if !ok {
-				return &FieldLoadError{ConfigName: n, FieldName: k,
-					Message: "expected value of type integer"}
-			}
+	case reflect.Ptr:
+		// Pointer fields distinguish a value which was not configured (nil) from a zero value
+		ptr := reflect.New(value.Type().Elem())
+		if err := setValue(ptr.Elem(), v, n, k); err != nil {
+			return err
+		}
 
-			value.SetInt(dt)
+		value.Set(ptr)
 
-		case reflect.String:
-			dt, ok := v.(string)
-			if !ok {
-				return &FieldLoadError{ConfigName: n, FieldName: k,
-					Message: "expected value of type string"}
-			}
+	case reflect.Map:
+		dt, ok := v.(map[string]interface{})
+		if !ok {
+			return &FieldLoadError{ConfigName: n, FieldName: k,
+				Message: "expected value map[string]interface{} ({ key1 = \"val1\", key2 = \"val2\" })"}
+		}
 
-			value.SetString(dt)
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
 
-		case reflect.Map:
-			dt, ok := v.(map[string]interface{})
-			if !ok {
-				return &FieldLoadError{ConfigName: n, FieldName: k,
-					Message: "expected value map[string]interface{} ({ key1 = \"val1\", key2 = \"val2\" })"}
-			}
+		for key, val := range dt {
+			kVal := reflect.ValueOf(key)
+			vVal := reflect.ValueOf(val)
+			value.SetMapIndex(kVal, vVal)
+		}
 
-			if value.IsNil() {
-				value.Set(reflect.MakeMap(value.Type()))
-			}
+	case reflect.Slice:
+		dt, ok := v.([]interface{})
+		if !ok {
+			return &FieldLoadError{ConfigName: n, FieldName: k,
+				Message: "expected value of type array"}
+		}
 
-			for key, val := range dt {
-				kVal := reflect.ValueOf(key)
-				vVal := reflect.ValueOf(val)
-				value.SetMapIndex(kVal, vVal)
-			}
+		if value.IsNil() {
+			value.Set(reflect.MakeSlice(value.Type(), len(dt), cap(dt)))
+		}
 
-		case reflect.Slice:
-			dt, ok := v.([]interface{})
+		for i, item := range dt {
+			val, ok := item.(string)
 			if !ok {
 				return &FieldLoadError{ConfigName: n, FieldName: k,
-					Message: "expected value of type array"}
-			}
-
-			if value.IsNil() {
-				value.Set(reflect.MakeSlice(value.Type(), len(dt), cap(dt)))
+					Message: fmt.Sprintf(`array item %d: expected value of type string (["a", "b", "c"])`, i)}
 			}
 
-			for i, item := range dt {
-				val, ok := item.(string)
-				if !ok {
-					return &FieldLoadError{ConfigName: n, FieldName: k,
-						Message: fmt.Sprintf(`array item %d: expected value of type string (["a", "b", "c"])`, i)}
-				}
-
-				value.Index(i).Set(reflect.ValueOf(val))
-			}
+			value.Index(i).Set(reflect.ValueOf(val))
 		}
 	}
 
@@ -319,10 +455,16 @@ func (e *FieldLoadError) Is(tgt error) bool {
 
 // DuplicateConfigNameError reports a duplicate configuration item name
 type DuplicateConfigNameError struct {
-	Name string
+	Name  string
+	Files []string // The config files which define the name, if it is defined in more than one
 }
 
 func (e *DuplicateConfigNameError) Error() string {
+	if len(e.Files) > 0 {
+		return fmt.Sprintf("duplicate config name: '%s' is defined in %s: all config names must be unique",
+			e.Name, strings.Join(e.Files, " and "))
+	}
+
 	return fmt.Sprintf("duplicate config name: '%s': all config names must be unique", e.Name)
 }
 
@@ -15,7 +15,7 @@ import (
 func TestParseConfiguration(t *testing.T) {
 	v := vipersFromString(mock.Config)
 
-	c, err := New(v)
+	c, err := New(v, false)
 	if err != nil {
 		t.Fatalf("unexpected error returned: %s", err)
 	}
@@ -35,6 +35,16 @@ func TestParseConfiguration(t *testing.T) {
 	settingstest := c.Settings["settingstest"]
 	checkFields(t, &settingstest)
 
+	if r := settingstest.RedirectClipboard; r == nil || *r {
+		t.Errorf("settingstest redirectclipboard was not loaded as false")
+	}
+	if a := settingstest.AudioMode; a == nil || *a != 1 {
+		t.Errorf("settingstest audiomode was not loaded as 1")
+	}
+	if settingstest.DrivesToRedirect != "*" {
+		t.Errorf("settingstest drivestoredirect was not loaded: got '%s'", settingstest.DrivesToRedirect)
+	}
+
 	tunneltest := c.Tunnels["tunneltest"]
 	checkFields(t, &tunneltest)
 
@@ -61,7 +71,7 @@ func TestParseConfiguration(t *testing.T) {
     getcred = true
     profile = "default"
     region = "eu-west-2"`)
-	_, err = New(v)
+	_, err = New(v, false)
 	if err == nil {
 		t.Errorf("no error returned when config has a duplicate key")
 	} else if !errors.Is(err, &DuplicateConfigNameError{}) {
@@ -75,7 +85,7 @@ func TestParseConfiguration(t *testing.T) {
     getcred = true
     profile = "default"
     region = "eu-west-2"`)
-	_, err = New(v)
+	_, err = New(v, false)
 	if err == nil {
 		t.Errorf("no error returned when config has an incorrect field value type")
 	} else if !errors.Is(err, &FieldLoadError{}) {
@@ -89,7 +99,7 @@ func TestParseConfiguration(t *testing.T) {
     getcred = true
     profile = "default"
     region = "eu-west-2"`)
-	_, err = New(v)
+	_, err = New(v, false)
 	if err == nil {
 		t.Errorf("no error returned when config has an incorrect field value type")
 	} else if !errors.Is(err, &FieldLoadError{}) {
@@ -100,12 +110,32 @@ func TestParseConfiguration(t *testing.T) {
 [settings.settingstest]
 	height = 500000
 	width = 200`)
-	_, err = New(v)
+	_, err = New(v, false)
 	if err == nil {
 		t.Errorf("no error returned when config has invalid values")
 	} else if !errors.Is(err, &InvalidConfigError{}) {
 		t.Errorf("unexpecred error returned: expected InvalidConfigError: got %T: %s", errors.Unwrap(err), err)
 	}
+
+	v = vipersFromString(`
+[settings.settingstest]
+	scalefactor = 120`)
+	_, err = New(v, false)
+	if err == nil {
+		t.Errorf("no error returned when config has an invalid rdp property")
+	} else if !errors.Is(err, &InvalidConfigError{}) {
+		t.Errorf("unexpecred error returned: expected InvalidConfigError: got %T: %s", errors.Unwrap(err), err)
+	}
+
+	v = vipersFromString(`
+[settings.settingstest]
+	client = "notaclient"`)
+	_, err = New(v, false)
+	if err == nil {
+		t.Errorf("no error returned when config has an invalid client name")
+	} else if !errors.Is(err, &InvalidConfigError{}) {
+		t.Errorf("unexpecred error returned: expected InvalidConfigError: got %T: %s", errors.Unwrap(err), err)
+	}
 }
 
 func checkFields(t *testing.T, str interface{}) {
@@ -153,7 +183,7 @@ func isZero(v reflect.Value) bool {
 		}
 		return z
 	case reflect.Ptr:
-		return isZero(reflect.Indirect(v))
+		return v.IsNil() || isZero(reflect.Indirect(v))
 	}
 	// Compare other types directly:
 	z := reflect.Zero(v.Type())
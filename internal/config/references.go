@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+)
+
+// reference is a reference from one config entry to another by name.
+type reference struct {
+	from   string   // Description of the entry with the reference, for example host 'web'
+	field  string   // Name of the field holding the reference
+	name   string   // Name of the entry referred to
+	target string   // Key of the entry referred to, for example cred.awssm.mycred, or empty if it doesn't exist
+	keys   []string // Names of the entries which could be referred to, used to suggest corrections
+}
+
+// references returns every reference between config entries: the global fields of hosts, the entries hosts and
+// templates extend, the hosts of tunnels and the hosts of groups.
+func (c *Configuration) references() []reference {
+	refs := make([]reference, 0)
+
+	for _, h := range sortedStrings(c.HostKeys()) {
+		for _, ref := range []struct {
+			global hosts.GlobalFields
+			key    func(string) string
+			keys   []string
+		}{
+			{hosts.GlobalCred, c.credKey, c.credKeys()},
+			{hosts.GlobalProxy, c.hostKey, c.HostKeys()},
+			{hosts.GlobalTunnel, c.tunnelKey, c.tunnelKeys()},
+			{hosts.GlobalSettings, c.settingsKey, c.settingsKeys()},
+		} {
+			if name := c.HostGlobals[h][ref.global.String()]; name != "" {
+				refs = append(refs, reference{from: fmt.Sprintf("host '%s'", h), field: ref.global.String(),
+					name: name, target: ref.key(name), keys: ref.keys})
+			}
+		}
+	}
+
+	extending := make([]string, 0, len(c.Extends))
+	for k := range c.Extends {
+		extending = append(extending, k)
+	}
+
+	for _, k := range sortedStrings(extending) {
+		e := Entry{Key: k}
+		hostType := strings.Split(k, ".")[1]
+		refs = append(refs, reference{from: fmt.Sprintf("%s '%s'", e.Kind(), e.Name()), field: ExtendsField,
+			name: c.Extends[k], target: c.parentKey(hostType, c.Extends[k]), keys: c.HostKeys()})
+	}
+
+	for _, t := range c.tunnelKeys() {
+		if name := c.Tunnels[t].Host; name != "" {
+			refs = append(refs, reference{from: fmt.Sprintf("tunnel '%s'", t), field: "host", name: name,
+				target: c.hostKey(name), keys: c.HostKeys()})
+		}
+	}
+
+	for _, g := range c.groupKeys() {
+		for _, h := range c.Groups[g].Hosts {
+			refs = append(refs, reference{from: fmt.Sprintf("group '%s'", g), field: "host", name: h,
+				target: c.hostKey(h), keys: c.HostKeys()})
+		}
+	}
+
+	return refs
+}
+
+// CheckReferences returns a *ReferenceError describing every reference to a config entry which doesn't exist and every
+// cycle of hosts which refer to each other through the 'proxy' global field or the host of a tunnel. It returns nil if
+// all references can be resolved.
+func (c *Configuration) CheckReferences() error {
+	problems := make([]string, 0)
+
+	for _, ref := range c.references() {
+		if ref.target == "" {
+			problems = append(problems, danglingReference(ref.from, ref.field, ref.name, ref.keys))
+		}
+	}
+
+	for _, cycle := range c.hostCycles() {
+		problems = append(problems, fmt.Sprintf("hosts refer to each other through proxy or tunnel: %s",
+			strings.Join(cycle, " -> ")))
+	}
+
+	if len(problems) > 0 {
+		return &ReferenceError{Problems: problems}
+	}
+
+	return nil
+}
+
+// ReferencesTo returns a description of each reference to the entry with the given key, for example cred.awssm.mycred.
+func (c *Configuration) ReferencesTo(key string) []string {
+	found := make([]string, 0)
+
+	for _, ref := range c.references() {
+		if ref.target == key {
+			found = append(found, fmt.Sprintf("%s refers to %s with '%s = \"%s\"'", ref.from, key, ref.field,
+				ref.name))
+		}
+	}
+
+	return found
+}
+
+// UnusedEntries returns the keys of cred, tunnel and settings entries which are not referred to by any host or tunnel,
+// for example cred.awssm.mycred. The default settings entry is never unused.
+func (c *Configuration) UnusedEntries() []string {
+	used := make(map[string]bool)
+
+	for _, g := range c.HostGlobals {
+		for _, global := range []hosts.GlobalFields{hosts.GlobalCred, hosts.GlobalTunnel, hosts.GlobalSettings} {
+			if name := g[global.String()]; name != "" {
+				used[global.String()+"."+name] = true
+			}
+		}
+	}
+
+	unused := make([]string, 0)
+
+	for _, k := range c.credKeys() {
+		if !used["cred."+k] {
+			unused = append(unused, fmt.Sprintf("cred.%s.%s", c.CredType(k), k))
+		}
+	}
+
+	for _, k := range c.tunnelKeys() {
+		if !used["tunnel."+k] {
+			unused = append(unused, "tunnel."+k)
+		}
+	}
+
+	for _, k := range c.settingsKeys() {
+		if !used["settings."+k] && k != DefaultSettingsName {
+			unused = append(unused, "settings."+k)
+		}
+	}
+
+	return unused
+}
+
+// hostCycles returns each cycle of hosts which refer to each other, where a host refers to its proxy host and the host
+// of its tunnel. Each cycle starts and ends with the same host.
+func (c *Configuration) hostCycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	cycles := make([][]string, 0)
+
+	var visit func(path []string)
+	visit = func(path []string) {
+		h := path[len(path)-1]
+		state[h] = visiting
+
+		for _, next := range c.hostReferences(h) {
+			switch state[next] {
+			case unvisited:
+				visit(append(path, next))
+			case visiting:
+				// Trim the path to the start of the cycle
+				for i, p := range path {
+					if p == next {
+						cycle := append([]string{}, path[i:]...)
+						cycles = append(cycles, append(cycle, next))
+						break
+					}
+				}
+			}
+		}
+
+		state[h] = visited
+	}
+
+	for _, h := range sortedStrings(c.HostKeys()) {
+		if state[h] == unvisited {
+			visit([]string{h})
+		}
+	}
+
+	return cycles
+}
+
+// hostReferences returns the names of the existing hosts which the given host refers to.
+func (c *Configuration) hostReferences(h string) []string {
+	refs := make([]string, 0)
+
+	if p := c.HostGlobals[h][hosts.GlobalProxy.String()]; c.HostExists(p) {
+		refs = append(refs, p)
+	}
+
+	if t, ok := c.Tunnels[c.HostGlobals[h][hosts.GlobalTunnel.String()]]; ok && c.HostExists(t.Host) {
+		refs = append(refs, t.Host)
+	}
+
+	return refs
+}
+
+func danglingReference(from, field, name string, keys []string) string {
+	msg := fmt.Sprintf("%s refers to %s '%s' which does not exist", from, field, name)
+	if s := closest(name, keys); s != "" {
+		msg += fmt.Sprintf(" (did you mean `%s`?)", s)
+	}
+
+	return msg
+}
+
+// The functions below return the key of the entry with the given name, or an empty string if it doesn't exist.
+
+func (c *Configuration) hostKey(name string) string {
+	if !c.HostExists(name) {
+		return ""
+	}
+
+	return fmt.Sprintf("host.%s.%s", c.HostType(name), name)
+}
+
+func (c *Configuration) credKey(name string) string {
+	if _, ok := c.Creds[name]; !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("cred.%s.%s", c.CredType(name), name)
+}
+
+func (c *Configuration) tunnelKey(name string) string {
+	if _, ok := c.Tunnels[name]; !ok {
+		return ""
+	}
+
+	return "tunnel." + name
+}
+
+func (c *Configuration) settingsKey(name string) string {
+	if _, ok := c.Settings[name]; !ok {
+		return ""
+	}
+
+	return "settings." + name
+}
+
+// parentKey returns the key of the entry extended by a host or template of the given type with 'extends = "name"'.
+// Templates are looked up before hosts, as they are when fields are inherited.
+func (c *Configuration) parentKey(hostType, name string) string {
+	if c.Templates[hostType+"."+name] {
+		return fmt.Sprintf("template.%s.%s", hostType, name)
+	}
+
+	if c.HostType(name) == hostType {
+		return c.hostKey(name)
+	}
+
+	return ""
+}
+
+func (c *Configuration) credKeys() []string {
+	keys := make([]string, 0, len(c.Creds))
+	for k := range c.Creds {
+		keys = append(keys, k)
+	}
+
+	return sortedStrings(keys)
+}
+
+func (c *Configuration) tunnelKeys() []string {
+	keys := make([]string, 0, len(c.Tunnels))
+	for k := range c.Tunnels {
+		keys = append(keys, k)
+	}
+
+	return sortedStrings(keys)
+}
+
+func (c *Configuration) settingsKeys() []string {
+	keys := make([]string, 0, len(c.Settings))
+	for k := range c.Settings {
+		keys = append(keys, k)
+	}
+
+	return sortedStrings(keys)
+}
+
+func (c *Configuration) groupKeys() []string {
+	keys := make([]string, 0, len(c.Groups))
+	for k := range c.Groups {
+		keys = append(keys, k)
+	}
+
+	return sortedStrings(keys)
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
+
+// ReferenceError reports references between config entries which can't be resolved.
+type ReferenceError struct {
+	Problems []string
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("invalid references between config entries:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+// Is implements Is(error) to support errors.Is
+func (e *ReferenceError) Is(tgt error) bool {
+	_, ok := tgt.(*ReferenceError)
+	return ok
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfiguration_CheckReferences(t *testing.T) {
+	v := vipersFromString(`
+[cred.awssm.mycred]
+    usernameid = "user"
+
+[cred.awssm.unusedcred]
+    usernameid = "user"
+
+[host.basic.web]
+    address = "10.0.0.1"
+    cred = "mycerd"
+    settings = "missing"
+    tunnel = "mytunnel"
+
+[host.basic.jump]
+    address = "10.0.0.2"
+    proxy = "relay"
+
+[host.basic.relay]
+    address = "10.0.0.3"
+    tunnel = "mytunnel"
+
+[tunnel.mytunnel]
+    host = "jump"
+    localport = "3390"
+
+[tunnel.unusedtunnel]
+    host = "nothost"
+
+[settings.default]
+    width = 800`)
+
+	if _, err := New(v, true); !errors.Is(err, &ReferenceError{}) {
+		t.Fatalf("unexpected error with strict references: expected ReferenceError: got %v", err)
+	}
+
+	c, err := New(v, false)
+	if err != nil {
+		t.Fatalf("unexpected error without strict references: %s", err)
+	}
+
+	var refErr *ReferenceError
+	if !errors.As(c.CheckReferences(), &refErr) {
+		t.Fatalf("no ReferenceError returned")
+	}
+
+	want := []string{
+		"host 'web' refers to cred 'mycerd' which does not exist (did you mean `mycred`?)",
+		"host 'web' refers to settings 'missing' which does not exist",
+		"tunnel 'unusedtunnel' refers to host 'nothost' which does not exist",
+		"hosts refer to each other through proxy or tunnel: jump -> relay -> jump",
+	}
+
+	if strings.Join(refErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\nwant:\n%s\ngot:\n%s", strings.Join(want, "\n"),
+			strings.Join(refErr.Problems, "\n"))
+	}
+
+	wantUnused := []string{"cred.awssm.mycred", "cred.awssm.unusedcred", "tunnel.unusedtunnel"}
+	if got := c.UnusedEntries(); strings.Join(got, ",") != strings.Join(wantUnused, ",") {
+		t.Errorf("unexpected unused entries: want %s: got %s", wantUnused, got)
+	}
+}
+
+func TestConfiguration_ReferencesTo(t *testing.T) {
+	c, err := New(vipersFromString(`
+[template.basic.base]
+    port = "3390"
+
+[host.basic.web]
+    address = "10.0.0.1"
+    extends = "base"
+    settings = "small"
+
+[host.basic.web2]
+    extends = "web"
+
+[host.basic.jump]
+    address = "10.0.0.2"
+
+[tunnel.mytunnel]
+    host = "jump"
+    localport = "3390"
+
+[group.all]
+    hosts = ["jump", "web"]
+
+[settings.small]
+    width = 800`), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for key, want := range map[string][]string{
+		"template.basic.base": {`host 'web' refers to template.basic.base with 'extends = "base"'`},
+		"host.basic.web": {
+			`host 'web2' refers to host.basic.web with 'extends = "web"'`,
+			`group 'all' refers to host.basic.web with 'host = "web"'`,
+		},
+		"host.basic.jump": {
+			`tunnel 'mytunnel' refers to host.basic.jump with 'host = "jump"'`,
+			`group 'all' refers to host.basic.jump with 'host = "jump"'`,
+		},
+		// Inherited global fields are references from the inheriting host too
+		"settings.small": {
+			`host 'web' refers to settings.small with 'settings = "small"'`,
+			`host 'web2' refers to settings.small with 'settings = "small"'`,
+		},
+		"tunnel.mytunnel": {},
+		"host.basic.web2": {},
+	} {
+		if got := c.ReferencesTo(key); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("unexpected references to %s:\nwant:\n%s\ngot:\n%s", key, strings.Join(want, "\n"),
+				strings.Join(got, "\n"))
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/runrdp/internal/rdp"
+)
 
 const DefaultSettingsName = "default"
 
-// Settings is the configuration of .RDP file settings.
+// Settings is the configuration of .RDP file settings. All rdp.Properties fields may be configured by their lower case
+// names, for example 'redirectclipboard = false'.
 // https://docs.microsoft.com/en-us/windows-server/remote/remote-desktop-services/clients/rdp-files
 type Settings struct {
 	Height     int  `mapstructure:"height"`
@@ -12,6 +18,10 @@ type Settings struct {
 	Fullscreen bool `mapstructure:"fullscreen"`
 	Public     bool `mapstructure:"public"`
 	Span       bool `mapstructure:"span"`
+
+	Client string `mapstructure:"client"` // Name of the rdp.Launcher used to start the session
+
+	rdp.Properties
 }
 
 // Validate returns an error if a config field is invalid.
@@ -24,17 +34,14 @@ func (s Settings) Validate() error {
 		return fmt.Errorf("height value %d is invalid, must be above 200 and below 8192\n", s.Height)
 	}
 
-	/*if s.Scale != 0 && func() bool {
-		// Scale is not in list of valid values
-		for _, v := range []int{100, 125, 150, 175, 200, 250, 300, 400, 500} {
-			if s.Scale == v {
-				return false
-			}
-		}
-		return true
-	}() {
-		return fmt.Errorf("scale value %d is invalid, must be one of 100, 125, 150, 175, 200, 250, 300, 400, 500\n", s.Scale)
-	}*/
+	if _, ok := rdp.Launchers[s.Client]; s.Client != "" && !ok {
+		return fmt.Errorf("client value '%s' is invalid, must be one of %s\n",
+			s.Client, strings.Join(rdp.LauncherNames(), ", "))
+	}
+
+	if err := s.Properties.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
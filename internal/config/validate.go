@@ -0,0 +1,434 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/danhale-git/runrdp/internal/config/creds"
+	"github.com/danhale-git/runrdp/internal/config/hosts"
+)
+
+// EntryKinds are the names of the top level tables which may be used in config files.
+var EntryKinds = []string{"host", "cred", "template", "settings", "tunnel", "group"}
+
+var syntaxErrorPattern = regexp.MustCompile(`^\((\d+), (\d+)\): (.*)$`)
+
+// Problem is a single problem found in a config file. Line and Column are 1-indexed, or 0 if the position is unknown.
+type Problem struct {
+	File       string
+	Line       int
+	Column     int
+	Message    string
+	Suggestion string // A valid name which is similar to the invalid one, if there is one
+}
+
+func (p Problem) String() string {
+	s := fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+	if p.Suggestion != "" {
+		s += fmt.Sprintf(" (did you mean `%s`?)", p.Suggestion)
+	}
+
+	return s
+}
+
+// Validate parses the given config files, by file path, and returns every problem found in them instead of stopping at
+// the first. Problems are sorted by file and position.
+func Validate(files map[string][]byte) []Problem {
+	return validate(files, nil)
+}
+
+// ValidateEnvironment validates the files of an environment directory with the other config files, in the same way as
+// Validate. Entries in envFiles replace entries of the same kind and name in files, as they do in ReplaceEntries.
+func ValidateEnvironment(files, envFiles map[string][]byte) []Problem {
+	all := make(map[string][]byte, len(files)+len(envFiles))
+	overrides := make(map[string]bool, len(envFiles))
+
+	for k, b := range files {
+		all[k] = b
+	}
+
+	for k, b := range envFiles {
+		all[k] = b
+		overrides[k] = true
+	}
+
+	return validate(all, overrides)
+}
+
+// validate returns the problems in files. Entries in the files in overrides replace entries in the other files instead
+// of being reported as duplicates.
+func validate(files map[string][]byte, overrides map[string]bool) []Problem {
+	v := validator{
+		problems:  make([]Problem, 0),
+		defined:   make(map[string]Problem),
+		vars:      make(map[string]string),
+		overrides: overrides,
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	// Parse all files first so variables defined in any file can be used in every file
+	trees := make(map[string]*toml.Tree)
+	for _, p := range paths {
+		tree, err := toml.LoadBytes(files[p])
+		if err != nil {
+			v.problems = append(v.problems, syntaxProblem(p, err))
+			continue
+		}
+
+		trees[p] = tree
+		v.collectVars(p, tree)
+	}
+
+	for _, p := range paths {
+		if tree, ok := trees[p]; ok {
+			v.file(p, tree)
+		}
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		a, b := v.problems[i], v.problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+	defined  map[string]Problem // Position of the first definition of each entry by <kind>.<name>
+	vars     map[string]string  // Variables from all [vars] tables
+
+	overrides map[string]bool // Files whose entries replace entries in the other files
+}
+
+func (v *validator) add(path string, pos toml.Position, suggestion, format string, a ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		File:       path,
+		Line:       pos.Line,
+		Column:     pos.Col,
+		Message:    fmt.Sprintf(format, a...),
+		Suggestion: suggestion,
+	})
+}
+
+// collectVars adds the variables defined in the vars table of a config file to v.vars.
+func (v *validator) collectVars(path string, tree *toml.Tree) {
+	for _, kind := range tree.Keys() {
+		if strings.ToLower(kind) != VarsKey {
+			continue
+		}
+
+		sub, ok := tree.GetPath([]string{kind}).(*toml.Tree)
+		if !ok {
+			v.add(path, tree.GetPositionPath([]string{kind}), "", "'%s' must be a table of variables", kind)
+			continue
+		}
+
+		data := sub.ToMap()
+
+		for _, name := range sortedKeys(sub) {
+			pos := sub.GetPositionPath([]string{name})
+
+			id := VarsKey + "." + strings.ToLower(name)
+			if first, ok := v.defined[id]; ok {
+				v.add(path, pos, "", "%s (first defined at %s:%d:%d)",
+					&DuplicateConfigNameError{Name: id}, first.File, first.Line, first.Column)
+				continue
+			}
+
+			v.defined[id] = Problem{File: path, Line: pos.Line, Column: pos.Col}
+
+			value, err := varValue(strings.ToLower(name), data[name])
+			if err != nil {
+				v.add(path, pos, "", "%s", err)
+				continue
+			}
+
+			v.vars[strings.ToLower(name)] = value
+		}
+	}
+}
+
+// file validates every entry in a single config file.
+func (v *validator) file(path string, tree *toml.Tree) {
+	for _, kind := range sortedKeys(tree) {
+		// Variables are checked by collectVars. Environment overlays may be partial entries so they are checked when
+		// the environment is used.
+		if strings.ToLower(kind) == VarsKey || strings.ToLower(kind) == EnvKey {
+			continue
+		}
+
+		if strings.ToLower(kind) == IncludeKey {
+			if _, err := includePatterns(tree.GetPath([]string{kind})); err != nil {
+				v.add(path, tree.GetPositionPath([]string{kind}), "", "%s", err)
+			}
+
+			continue
+		}
+
+		pos := tree.GetPositionPath([]string{kind})
+
+		sub, ok := tree.GetPath([]string{kind}).(*toml.Tree)
+		if !ok {
+			v.add(path, pos, "", "'%s' must be a table of config entries", kind)
+			continue
+		}
+
+		switch strings.ToLower(kind) {
+		case "host":
+			v.typedEntries(path, sub, kind, hosts.Map)
+		case "cred":
+			v.typedEntries(path, sub, kind, creds.Map)
+		case "template":
+			v.typedEntries(path, sub, kind, hosts.Map)
+		case "settings":
+			v.entries(path, sub, kind, func() interface{} { return &Settings{} })
+		case "tunnel":
+			v.entries(path, sub, kind, func() interface{} { return &Tunnel{} })
+		case "group":
+			v.entries(path, sub, kind, func() interface{} { return &Group{} })
+		default:
+			v.add(path, pos, closest(strings.ToLower(kind), EntryKinds),
+				"'%s' is not a config type, must be one of %s", kind, strings.Join(EntryKinds, ", "))
+		}
+	}
+}
+
+// typedEntries validates the entries of a kind which has sub types, such as host.<type>.<name>.
+func (v *validator) typedEntries(path string, tree *toml.Tree, kind string, m map[string]func() interface{}) {
+	for _, t := range sortedKeys(tree) {
+		pos := tree.GetPositionPath([]string{t})
+
+		typeFunc, ok := m[strings.ToLower(t)]
+		if !ok {
+			v.add(path, pos, closest(strings.ToLower(t), sortedMapKeys(m)),
+				"'%s' is not a %s type, must be one of %s", t, kind, mapKeys(m))
+			continue
+		}
+
+		sub, ok := tree.GetPath([]string{t}).(*toml.Tree)
+		if !ok {
+			v.add(path, pos, "", "'%s.%s' must be a table of config entries", kind, t)
+			continue
+		}
+
+		v.entries(path, sub, fmt.Sprintf("%s.%s", kind, t), typeFunc)
+	}
+}
+
+// entries validates each entry in tree, where prefix is the key of tree, for example host.awsec2.
+func (v *validator) entries(path string, tree *toml.Tree, prefix string, typeFunc func() interface{}) {
+	for _, name := range sortedKeys(tree) {
+		pos := tree.GetPositionPath([]string{name})
+		key := strings.ToLower(fmt.Sprintf("%s.%s", prefix, name))
+
+		sub, ok := tree.GetPath([]string{name}).(*toml.Tree)
+		if !ok {
+			v.add(path, pos, "", "%s must be a table", key)
+			continue
+		}
+
+		e := Entry{Key: key}
+		id := e.ID()
+
+		first, ok := v.defined[id]
+		switch {
+		case !ok:
+			v.defined[id] = Problem{File: path, Line: pos.Line, Column: pos.Col}
+		case v.overrides[path] != v.overrides[first.File]:
+			// An environment directory entry replaces the entry in the other config files
+			if v.overrides[path] {
+				v.defined[id] = Problem{File: path, Line: pos.Line, Column: pos.Col}
+			}
+		default:
+			v.add(path, pos, "", "%s (first defined at %s:%d:%d)",
+				&DuplicateConfigNameError{Name: e.Name()}, first.File, first.Line, first.Column)
+		}
+
+		v.entry(path, sub, key, typeFunc)
+	}
+}
+
+// entry validates the fields of a single entry in the same way as they are parsed by New, reporting each invalid field
+// separately.
+func (v *validator) entry(path string, tree *toml.Tree, key string, typeFunc func() interface{}) {
+	isHost := isHostKey(key)
+
+	entry := typeFunc()
+	value := reflect.ValueOf(entry).Elem()
+
+	valueMap := make(map[string]reflect.Value)
+	mapFields(value, valueMap)
+
+	names := make([]string, 0, len(valueMap))
+	for n := range valueMap {
+		names = append(names, n)
+	}
+	if isHost {
+		names = append(names, hosts.GlobalFieldNames()...)
+		names = append(names, ExtendsField, TagsField)
+	}
+
+	sort.Strings(names)
+
+	data := tree.ToMap()
+
+	for _, k := range sortedKeys(tree) {
+		pos := tree.GetPositionPath([]string{k})
+		field := strings.ToLower(k)
+
+		raw, err := interpolateFields(map[string]interface{}{field: data[k]}, v.vars, key)
+		if err != nil {
+			v.add(path, pos, "", "%s", err)
+			continue
+		}
+
+		if isHost && field == ExtendsField {
+			if _, ok := data[k].(string); !ok {
+				v.add(path, pos, "", "%s: '%s' must be a string", key, ExtendsField)
+			}
+
+			continue
+		}
+
+		if isHost && field == TagsField {
+			if _, err := hostTags(raw, key); err != nil {
+				v.add(path, pos, "", "%s", err)
+			}
+
+			continue
+		}
+
+		if isHost && hosts.FieldNameIsGlobal(field) {
+			if _, err := getGlobals(raw); err != nil {
+				v.add(path, pos, "", "%s: %s", key, err)
+			}
+
+			continue
+		}
+
+		if _, ok := valueMap[field]; !ok {
+			v.add(path, pos, closest(field, names),
+				"config key %s is invalid for type %s", k, value.Type().Name())
+			continue
+		}
+
+		if err := setFields(value, raw); err != nil {
+			v.add(path, pos, "", "%s", err)
+		}
+	}
+
+	validator, ok := entry.(interface{ Validate() error })
+	if !ok {
+		return
+	}
+
+	if err := validator.Validate(); err != nil {
+		v.add(path, tree.Position(), "", "%s configuration is invalid: %s", key, strings.TrimSpace(err.Error()))
+	}
+}
+
+// syntaxProblem converts an error returned by the TOML parser to a Problem.
+func syntaxProblem(path string, err error) Problem {
+	p := Problem{File: path, Message: err.Error()}
+
+	if m := syntaxErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		p.Line, _ = strconv.Atoi(m[1])
+		p.Column, _ = strconv.Atoi(m[2])
+		p.Message = m[3]
+	}
+
+	p.Message = "syntax error: " + p.Message
+
+	return p
+}
+
+func sortedKeys(t *toml.Tree) []string {
+	keys := t.Keys()
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedMapKeys(m map[string]func() interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// closest returns the string in options which is most similar to s, or an empty string if none of them are similar
+// enough to be a likely typo.
+func closest(s string, options []string) string {
+	best, bestDistance := "", len(s)/4+2
+
+	for _, o := range options {
+		if d := levenshtein(s, o); d < bestDistance {
+			best, bestDistance = o, d
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the number of single character edits required to change a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev = cur
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/danhale-git/runrdp/internal/mock"
+)
+
+func TestValidate(t *testing.T) {
+	if problems := Validate(map[string][]byte{"mock.toml": []byte(mock.Config)}); len(problems) > 0 {
+		t.Errorf("unexpected problems in mock config: %v", problems)
+	}
+
+	problems := Validate(map[string][]byte{
+		"a.toml": []byte(`[host.awsec2.one]
+    id = "i-12345abc"
+    filterjosn = "[]"
+    private = 1234
+
+[host.bsaic.two]
+    address = "10.0.0.1"
+
+[settings.small]
+    width = 10
+`),
+		"b.toml": []byte(`[host.basic.one]
+    address = 1234
+
+[tunel.mytunnel]
+    host = "one"
+`),
+		"c.toml": []byte(`[host.basic.three]
+    address = "10.0.0.3
+`),
+		"d.toml": []byte(`[host.basic.four]
+    address = "10.0.0.4"
+    tags = "prod"
+
+[host.basic.five]
+    address = "10.0.0.5"
+    tags = ["prod"]
+
+[group.prod]
+    tags = ["prod"]
+    hsots = ["four"]
+`),
+	})
+
+	want := []Problem{
+		{File: "a.toml", Line: 3, Column: 5, Suggestion: "filterjson"},
+		{File: "a.toml", Line: 4, Column: 5},
+		{File: "a.toml", Line: 6, Column: 1, Suggestion: "basic"},
+		{File: "a.toml", Line: 9, Column: 1},
+		{File: "b.toml", Line: 1, Column: 1},
+		{File: "b.toml", Line: 2, Column: 5},
+		{File: "b.toml", Line: 4, Column: 1, Suggestion: "tunnel"},
+		{File: "c.toml", Line: 2, Column: 16},
+		{File: "d.toml", Line: 3, Column: 5},
+		{File: "d.toml", Line: 11, Column: 5, Suggestion: "hosts"},
+	}
+
+	if len(problems) != len(want) {
+		t.Fatalf("unexpected number of problems: want %d: got %d: %v", len(want), len(problems), problems)
+	}
+
+	for i, w := range want {
+		p := problems[i]
+		if p.File != w.File || p.Line != w.Line || p.Column != w.Column || p.Suggestion != w.Suggestion {
+			t.Errorf("unexpected problem %d: want %s:%d:%d (%s): got %s", i, w.File, w.Line, w.Column,
+				w.Suggestion, p)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	options := []string{"filterjson", "getcred", "id", "private", "profile", "region"}
+
+	for in, want := range map[string]string{
+		"filterjosn": "filterjson",
+		"regoin":     "region",
+		"privat":     "private",
+		"ip":         "id",
+		"address":    "",
+	} {
+		if got := closest(in, options); got != want {
+			t.Errorf("unexpected suggestion for '%s': want '%s': got '%s'", in, want, got)
+		}
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	problems := ValidateEnvironment(map[string][]byte{
+		"z.toml": []byte(`[host.basic.web]
+    address = "10.0.0.1"
+`),
+	}, map[string][]byte{
+		"env/prod/a.toml": []byte(`[host.awsec2.web]
+    id = "i-12345abc"
+`),
+		"env/prod/b.toml": []byte(`[host.basic.web]
+    address = "10.0.0.2"
+`),
+	})
+
+	// Replacing an entry in the other files is allowed, defining it twice in the environment is not
+	if len(problems) != 1 || problems[0].File != "env/prod/b.toml" || problems[0].Line != 1 {
+		t.Errorf("unexpected problems: want a duplicate in env/prod/b.toml: got %v", problems)
+	}
+}
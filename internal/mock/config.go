@@ -9,9 +9,80 @@ import (
 const Config = `[cred.awssm.awssmtest]
     usernameid = "TestInstanceUsername"
     passwordid = "TestInstancePassword"
+    secretid = "TestInstanceCredentials"
+    usernamekey = "user"
+    passwordkey = "pass"
+    versionstage = "AWSCURRENT"
+    versionid = "EXAMPLE1-90ab-cdef-fedc-ba987EXAMPLE"
     region = "eu-west-2"
     profile = "default"
 
+[cred.awsssm.awsssmtest]
+    usernameparam = "/rdp/username"
+    passwordparam = "/rdp/password"
+    region = "eu-west-2"
+    profile = "default"
+
+[cred.env.envtest]
+    usernamevar = "TEST_USERNAME"
+    passwordvar = "TEST_PASSWORD"
+
+[cred.prompt.prompttest]
+    message = "Test password: "
+    confirm = true
+
+[cred.vault.vaulttest]
+    server = "https://vault.example.com:8200"
+    mount = "secret"
+    path = "rdp/test"
+    kvversion = 2
+    usernamekey = "user"
+    passwordkey = "pass"
+    namespace = "team"
+    roleid = "myrole"
+    secretid = "mysecret"
+
+[cred.exec.exectest]
+    command = "sh"
+    args = ["-c", "op read op://Work/test/username && op read op://Work/test/password"]
+    timeout = 60
+    passenv = ["PATH", "HOME", "OP_SESSION"]
+    env = ["OP_ACCOUNT=test"]
+
+[cred.file.filetest]
+    path = "~/secrets.yaml.age"
+    key = "servers.test"
+    usernamekey = "login"
+    passwordkey = "secret"
+    identity = "~/.config/sops/age/keys.txt"
+    sops = "/usr/local/bin/sops"
+
+[cred.keepass.keepasstest]
+    file = "~/secrets.kdbx"
+    keyfile = "~/secrets.keyx"
+    nopassword = true
+    entry = "Servers/test"
+
+[cred.keyring.keyringtest]
+    service = "runrdptest"
+    account = "testaccount"
+
+[cred.pass.passtest]
+    entry = "work/rdp/test"
+    storedir = "~/.password-store"
+    binary = "gopass"
+
+[cred.tss.tsstest]
+    server = "https://example.com/SecretServer"
+    apiuser = "apiuser"
+    apipassword = "apipassword"
+    secretname = "web"
+    folderid = 10
+    usernamefield = "username"
+    passwordfield = "password"
+    domainfield = "domain"
+    upn = true
+
 [host.awsec2.awsec2test]
     id = "i-12345abc"
 	tunnel = "mytunnel"
@@ -49,12 +120,26 @@ const Config = `[cred.awssm.awssmtest]
 	fullscreen = true
 	span = true
 	public = true
+	client = "print"
+	redirectclipboard = false
+	audiomode = 1
+	drivestoredirect = "*"
 `
 
 // ConfigKeys returns a slice containing all expected mock config keys
 func ConfigKeys() []string {
 	return []string{
 		"cred.awssm.awssmtest",
+		"cred.awsssm.awsssmtest",
+		"cred.env.envtest",
+		"cred.prompt.prompttest",
+		"cred.vault.vaulttest",
+		"cred.keyring.keyringtest",
+		"cred.keepass.keepasstest",
+		"cred.exec.exectest",
+		"cred.file.filetest",
+		"cred.pass.passtest",
+		"cred.tss.tsstest",
 		"host.awsec2.awsec2test",
 		"host.basic.basictest",
 		"tunnel.tunneltest",
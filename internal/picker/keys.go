@@ -0,0 +1,107 @@
+package picker
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeyType identifies a key press which is handled by the picker.
+type KeyType int
+
+const (
+	KeyRune      KeyType = iota // A printable character
+	KeyEnter                    // Enter or Return
+	KeyEscape                   // Esc
+	KeyInterrupt                // Ctrl+C
+	KeyBackspace                // Backspace
+	KeyClear                    // Ctrl+U
+	KeyUp                       // Up arrow or Ctrl+P
+	KeyDown                     // Down arrow or Ctrl+N
+	KeyPageUp                   // Page Up
+	KeyPageDown                 // Page Down
+)
+
+// Key is a single key press. Rune is only set for KeyRune.
+type Key struct {
+	Type KeyType
+	Rune rune
+}
+
+// escapeSequences maps the terminal escape sequences for special keys, without the leading ESC, to their key type.
+var escapeSequences = map[string]KeyType{
+	"[A":  KeyUp,
+	"[B":  KeyDown,
+	"OA":  KeyUp,
+	"OB":  KeyDown,
+	"[5~": KeyPageUp,
+	"[6~": KeyPageDown,
+}
+
+// controlKeys maps control characters to their key type.
+var controlKeys = map[byte]KeyType{
+	'\r': KeyEnter,
+	'\n': KeyEnter,
+	0x03: KeyInterrupt,
+	0x08: KeyBackspace,
+	0x7f: KeyBackspace,
+	0x15: KeyClear,
+	0x10: KeyUp,
+	0x0e: KeyDown,
+}
+
+// ParseKeys converts bytes read from a terminal in raw mode to key presses. Escape sequences and control characters
+// which the picker doesn't handle are ignored. An ESC which is not the start of a known escape sequence is KeyEscape.
+func ParseKeys(b []byte) []Key {
+	keys := make([]Key, 0)
+
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			n, key, ok := parseEscape(b[1:])
+			if ok {
+				keys = append(keys, key)
+			}
+
+			b = b[1+n:]
+
+			continue
+		}
+
+		if t, ok := controlKeys[b[0]]; ok {
+			keys = append(keys, Key{Type: t})
+			b = b[1:]
+
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b)
+		if r != utf8.RuneError && unicode.IsPrint(r) {
+			keys = append(keys, Key{Type: KeyRune, Rune: r})
+		}
+
+		b = b[size:]
+	}
+
+	return keys
+}
+
+// parseEscape parses the bytes following an ESC. It returns the number of bytes which belong to the escape sequence and
+// the key, if the sequence is one the picker handles.
+func parseEscape(b []byte) (int, Key, bool) {
+	if len(b) == 0 || (b[0] != '[' && b[0] != 'O') {
+		return 0, Key{Type: KeyEscape}, true
+	}
+
+	// A control sequence ends with a byte in the range 0x40 to 0x7e
+	end := 1
+	for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+		end++
+	}
+
+	if end == len(b) {
+		return len(b), Key{}, false
+	}
+
+	t, ok := escapeSequences[string(b[:end+1])]
+
+	return end + 1, Key{Type: t}, ok
+}
@@ -0,0 +1,199 @@
+// Package picker implements an interactive full screen fuzzy finder for choosing one item from a list.
+package picker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sahilm/fuzzy"
+	"golang.org/x/term"
+)
+
+// ErrCancelled is returned by Run when the user closes the picker without choosing an item.
+var ErrCancelled = errors.New("cancelled")
+
+// ErrNotTerminal is returned by Run when stdin or stdout is not a terminal.
+var ErrNotTerminal = errors.New("stdin and stdout must be a terminal")
+
+const (
+	enterAltScreen = "\x1b[?1049h"
+	exitAltScreen  = "\x1b[?1049l"
+)
+
+// PreviewFunc returns lines describing an item, which are shown next to the list of matches when it is selected.
+type PreviewFunc func(item string) []string
+
+// Picker holds the state of an interactive picker. It is independent of the terminal so it can be driven by key
+// presses from any source.
+type Picker struct {
+	items   []string
+	preview PreviewFunc
+
+	query     []rune
+	matches   fuzzy.Matches
+	cursor    int // Index in matches of the selected item
+	offset    int // Index in matches of the first visible item
+	cancelled bool
+}
+
+// New returns a Picker for the given items, filtered by the initial query. preview may be nil.
+func New(items []string, query string, preview PreviewFunc) *Picker {
+	sorted := make([]string, len(items))
+	copy(sorted, items)
+	sort.Strings(sorted)
+
+	p := &Picker{items: sorted, preview: preview, query: []rune(query)}
+	p.filter()
+
+	return p
+}
+
+// Run shows a picker for the given items on the terminal and returns the item chosen with Enter. ErrCancelled is
+// returned if the user presses Esc or Ctrl+C.
+func Run(items []string, query string, preview PreviewFunc) (string, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return "", ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return "", fmt.Errorf("setting terminal to raw mode: %w", err)
+	}
+
+	defer func() {
+		fmt.Print(exitAltScreen)
+		_ = term.Restore(in, state)
+	}()
+
+	fmt.Print(enterAltScreen)
+
+	p := New(items, query, preview)
+	buf := make([]byte, 256)
+
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+
+		var screen bytes.Buffer
+
+		p.Render(&screen, width, height)
+
+		if _, err := os.Stdout.Write(screen.Bytes()); err != nil {
+			return "", err
+		}
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("reading input: %w", err)
+		}
+
+		for _, k := range ParseKeys(buf[:n]) {
+			if p.Handle(k) {
+				return p.Result()
+			}
+		}
+	}
+}
+
+// Handle updates the picker with a key press. It returns true if the picker is finished, either because an item was
+// chosen or because it was cancelled.
+func (p *Picker) Handle(k Key) bool {
+	switch k.Type {
+	case KeyRune:
+		p.query = append(p.query, k.Rune)
+		p.filter()
+	case KeyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case KeyClear:
+		p.query = p.query[:0]
+		p.filter()
+	case KeyUp:
+		p.move(-1)
+	case KeyDown:
+		p.move(1)
+	case KeyPageUp:
+		p.move(-10)
+	case KeyPageDown:
+		p.move(10)
+	case KeyEnter:
+		return len(p.matches) > 0
+	case KeyEscape, KeyInterrupt:
+		p.cancelled = true
+		return true
+	}
+
+	return false
+}
+
+// Result returns the selected item, or ErrCancelled if the picker was cancelled.
+func (p *Picker) Result() (string, error) {
+	s, ok := p.Selected()
+	if p.cancelled || !ok {
+		return "", ErrCancelled
+	}
+
+	return s, nil
+}
+
+// Query returns the text typed by the user.
+func (p *Picker) Query() string {
+	return string(p.query)
+}
+
+// Matches returns the items matching the query, best match first.
+func (p *Picker) Matches() []string {
+	matches := make([]string, len(p.matches))
+	for i, m := range p.matches {
+		matches[i] = m.Str
+	}
+
+	return matches
+}
+
+// Selected returns the currently selected item and false if no items match the query.
+func (p *Picker) Selected() (string, bool) {
+	if len(p.matches) == 0 {
+		return "", false
+	}
+
+	return p.matches[p.cursor].Str, true
+}
+
+// filter ranks the items by the query and selects the best match. All items are shown in alphabetical order if the
+// query is empty.
+func (p *Picker) filter() {
+	p.cursor, p.offset = 0, 0
+
+	if len(p.query) == 0 {
+		p.matches = make(fuzzy.Matches, len(p.items))
+		for i, s := range p.items {
+			p.matches[i] = fuzzy.Match{Str: s, Index: i}
+		}
+
+		return
+	}
+
+	p.matches = fuzzy.Find(string(p.query), p.items)
+}
+
+// move moves the cursor by n matches, stopping at the first and last match.
+func (p *Picker) move(n int) {
+	p.cursor += n
+
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
@@ -0,0 +1,150 @@
+package picker
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+func typeKeys(p *Picker, s string) bool {
+	for _, k := range ParseKeys([]byte(s)) {
+		if p.Handle(k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestParseKeys(t *testing.T) {
+	want := []Key{
+		{Type: KeyRune, Rune: 'a'},
+		{Type: KeyRune, Rune: 'é'},
+		{Type: KeyUp},
+		{Type: KeyDown},
+		{Type: KeyUp},
+		{Type: KeyPageDown},
+		{Type: KeyBackspace},
+		{Type: KeyClear},
+		{Type: KeyEnter},
+		{Type: KeyInterrupt},
+		{Type: KeyEscape},
+	}
+
+	// Unhandled sequences (right arrow, tab) are ignored
+	got := ParseKeys([]byte("aé\x1b[A\x1b[B\x10\x1b[6~\x1b[C\t\x7f\x15\r\x03\x1b"))
+
+	if len(got) != len(want) {
+		t.Fatalf("unexpected keys: want %v: got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected key %d: want %v: got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestPicker_Handle(t *testing.T) {
+	p := New([]string{"webserver", "database", "jumpbox", "webcache"}, "", nil)
+
+	if got := p.Matches(); strings.Join(got, ",") != "database,jumpbox,webcache,webserver" {
+		t.Errorf("unexpected matches for empty query: %v", got)
+	}
+
+	typeKeys(p, "wbs")
+
+	if got, _ := p.Selected(); got != "webserver" {
+		t.Errorf("unexpected selection for query '%s': want webserver: got %s", p.Query(), got)
+	}
+
+	// Backspace widens the search again
+	typeKeys(p, "\x7f\x7f")
+
+	if got := p.Matches(); len(got) != 2 {
+		t.Errorf("unexpected matches for query '%s': %v", p.Query(), got)
+	}
+
+	// Moving past the last match stops at the last match
+	typeKeys(p, "\x1b[B\x1b[B\x1b[B")
+
+	last := p.Matches()[len(p.Matches())-1]
+	if got, _ := p.Selected(); got != last {
+		t.Errorf("unexpected selection after moving down: want %s: got %s", last, got)
+	}
+
+	if !typeKeys(p, "\r") {
+		t.Fatal("picker did not finish when enter was pressed")
+	}
+
+	if got, err := p.Result(); err != nil || got != last {
+		t.Errorf("unexpected result: want %s: got %s (%v)", last, got, err)
+	}
+}
+
+func TestPicker_HandleNoMatches(t *testing.T) {
+	p := New([]string{"webserver"}, "xyz", nil)
+
+	if typeKeys(p, "\r") {
+		t.Error("picker finished when enter was pressed with no matches")
+	}
+
+	if !typeKeys(p, "\x1b") {
+		t.Fatal("picker did not finish when escape was pressed")
+	}
+
+	if _, err := p.Result(); !errors.Is(err, ErrCancelled) {
+		t.Errorf("unexpected error: want %s: got %v", ErrCancelled, err)
+	}
+}
+
+func TestPicker_Render(t *testing.T) {
+	preview := func(item string) []string {
+		return []string{"[host.basic." + item + "]", "address = \"10.0.0.1\""}
+	}
+
+	items := []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}
+
+	p := New(items, "", preview)
+
+	// Select an item below the visible rows so the list scrolls
+	typeKeys(p, "\x1b[B\x1b[B\x1b[B\x1b[B")
+
+	var b bytes.Buffer
+
+	p.Render(&b, 80, 4)
+
+	lines := strings.Split(escapes.ReplaceAllString(b.String(), ""), "\r\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected number of lines: want 4: got %d:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+
+	if lines[0] != ">   6/6" {
+		t.Errorf("unexpected prompt line: '%s'", lines[0])
+	}
+
+	if !strings.HasPrefix(lines[3], "> echo ") {
+		t.Errorf("selected item is not the last visible line: '%s'", lines[3])
+	}
+
+	if !strings.HasSuffix(lines[1], "│ [host.basic.echo]") {
+		t.Errorf("preview of the selected item is not shown: '%s'", lines[1])
+	}
+
+	// Matched characters are highlighted
+	p = New(items, "fox", preview)
+	b.Reset()
+	p.Render(&b, 40, 3)
+
+	if !strings.Contains(b.String(), styleHighlight+"f"+styleReset) {
+		t.Errorf("matched character is not highlighted: %q", b.String())
+	}
+
+	if strings.Contains(b.String(), separator) {
+		t.Errorf("preview is shown in a narrow terminal")
+	}
+}
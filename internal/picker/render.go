@@ -0,0 +1,138 @@
+package picker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	styleReset     = "\x1b[0m"
+	styleBold      = "\x1b[1m"
+	styleDim       = "\x1b[2m"
+	styleHighlight = "\x1b[1;33m"
+	clearLine      = "\x1b[K"
+	clearBelow     = "\x1b[J"
+	cursorHome     = "\x1b[H"
+
+	minPreviewWidth = 60 // The preview pane is hidden if the terminal is narrower than this
+	separator       = " │ "
+)
+
+// Render writes the picker to w as a full screen of the given size. The first line is the query, followed by the
+// matching items with the selected item's preview to the right.
+func (p *Picker) Render(w io.Writer, width, height int) {
+	rows := height - 1
+	if rows < 1 {
+		rows = 1
+	}
+
+	p.scroll(rows)
+
+	listWidth, previewWidth := width, 0
+	if p.preview != nil && width >= minPreviewWidth {
+		listWidth = width / 2
+		previewWidth = width - listWidth - len([]rune(separator))
+	}
+
+	var preview []string
+	if s, ok := p.Selected(); ok && previewWidth > 0 {
+		preview = p.preview(s)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(cursorHome)
+	b.WriteString(fmt.Sprintf("> %s  %s%d/%d%s%s", string(p.query),
+		styleDim, len(p.matches), len(p.items), styleReset, clearLine))
+
+	for i := 0; i < rows; i++ {
+		b.WriteString("\r\n")
+
+		line, length := "", 0
+		if m := p.offset + i; m < len(p.matches) {
+			line, length = matchLine(p.matches[m].Str, p.matches[m].MatchedIndexes, m == p.cursor, listWidth)
+		}
+
+		b.WriteString(line)
+
+		if previewWidth > 0 {
+			b.WriteString(strings.Repeat(" ", listWidth-length))
+			b.WriteString(styleDim + separator + styleReset)
+
+			if i < len(preview) {
+				b.WriteString(truncate(preview[i], previewWidth))
+			}
+		}
+
+		b.WriteString(clearLine)
+	}
+
+	b.WriteString(clearBelow)
+
+	// Leave the terminal cursor at the end of the query
+	b.WriteString(fmt.Sprintf("\x1b[1;%dH", len(p.query)+3))
+
+	_, _ = io.WriteString(w, b.String())
+}
+
+// scroll moves the visible window of matches so the cursor is visible in the given number of rows.
+func (p *Picker) scroll(rows int) {
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+
+	if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+}
+
+// matchLine formats an item in the list of matches, highlighting the characters at the given byte indexes. It returns
+// the line and the number of characters which are visible in the terminal.
+func matchLine(s string, matched []int, selected bool, width int) (string, int) {
+	style := ""
+	prefix := "  "
+
+	if selected {
+		style = styleBold
+		prefix = "> "
+	}
+
+	isMatched := make(map[int]bool, len(matched))
+	for _, i := range matched {
+		isMatched[i] = true
+	}
+
+	var b strings.Builder
+
+	b.WriteString(style + prefix)
+	length := len(prefix)
+
+	for i, r := range s {
+		if length >= width {
+			break
+		}
+
+		if isMatched[i] {
+			b.WriteString(styleHighlight + string(r) + styleReset + style)
+		} else {
+			b.WriteRune(r)
+		}
+
+		length++
+	}
+
+	b.WriteString(styleReset)
+
+	return b.String(), length
+}
+
+// truncate shortens s to at most width characters.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width])
+	}
+
+	return s
+}
@@ -0,0 +1,46 @@
+package rdp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Linux RDP client executables in order of preference.
+const (
+	XFreeRDP  = "xfreerdp"
+	WLFreeRDP = "wlfreerdp"
+	Remmina   = "remmina"
+)
+
+// ClientNotFoundError reports that none of the supported RDP client executables are installed.
+type ClientNotFoundError struct {
+	Clients []string
+}
+
+func (e *ClientNotFoundError) Error() string {
+	return fmt.Sprintf("no supported rdp client was found in PATH: install one of %s",
+		strings.Join(e.Clients, ", "))
+}
+
+// Is implements Is(error) to support errors.Is
+func (e *ClientNotFoundError) Is(tgt error) bool {
+	_, ok := tgt.(*ClientNotFoundError)
+	return ok
+}
+
+// findClient returns the name and path of the preferred installed RDP client. lookPath should be exec.LookPath. If
+// wayland is true, wlfreerdp is preferred over xfreerdp.
+func findClient(lookPath func(string) (string, error), wayland bool) (string, string, error) {
+	clients := []string{XFreeRDP, WLFreeRDP, Remmina}
+	if wayland {
+		clients = []string{WLFreeRDP, XFreeRDP, Remmina}
+	}
+
+	for _, c := range clients {
+		if path, err := lookPath(c); err == nil {
+			return c, path, nil
+		}
+	}
+
+	return "", "", &ClientNotFoundError{Clients: clients}
+}
@@ -0,0 +1,54 @@
+package rdp
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func lookPathFunc(installed ...string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		for _, i := range installed {
+			if i == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestFindClient(t *testing.T) {
+	tests := []struct {
+		installed []string
+		wayland   bool
+		want      string
+	}{
+		{[]string{XFreeRDP, WLFreeRDP, Remmina}, false, XFreeRDP},
+		{[]string{XFreeRDP, WLFreeRDP, Remmina}, true, WLFreeRDP},
+		{[]string{XFreeRDP, Remmina}, true, XFreeRDP},
+		{[]string{Remmina}, false, Remmina},
+	}
+
+	for _, tt := range tests {
+		got, path, err := findClient(lookPathFunc(tt.installed...), tt.wayland)
+		if err != nil {
+			t.Errorf("unexpected error returned with %s installed: %s", tt.installed, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("unexpected client with %s installed (wayland %t): want %s: got %s",
+				tt.installed, tt.wayland, tt.want, got)
+		}
+
+		if path != "/usr/bin/"+got {
+			t.Errorf("unexpected path returned for %s: %s", got, path)
+		}
+	}
+
+	_, _, err := findClient(lookPathFunc(), false)
+	if !errors.Is(err, &ClientNotFoundError{}) {
+		t.Errorf("unexpected error returned with no clients installed: expected ClientNotFoundError: got %v", err)
+	}
+}
@@ -1,6 +0,0 @@
-package rdp
-
-// Connect is included here to avoid compile errors when CI runs unit tests on linux
-func Connect(rdp *RDP, debug bool) error {
-	return nil
-}
@@ -0,0 +1,11 @@
+package rdp
+
+// defaultLauncher returns the RDPFile launcher, which opens Microsoft Remote Desktop.
+func defaultLauncher() (Launcher, error) {
+	return RDPFileLauncher(), nil
+}
+
+// platformLaunchers returns the launchers which are only available on macOS, of which there are none.
+func platformLaunchers() map[string]func() Launcher {
+	return nil
+}
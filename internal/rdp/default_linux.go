@@ -0,0 +1,21 @@
+package rdp
+
+import (
+	"os"
+	"os/exec"
+)
+
+// defaultLauncher returns the launcher for the first supported RDP client found in PATH.
+func defaultLauncher() (Launcher, error) {
+	client, _, err := findClient(exec.LookPath, os.Getenv("WAYLAND_DISPLAY") != "")
+	if err != nil {
+		return nil, err
+	}
+
+	return Launchers[client](), nil
+}
+
+// platformLaunchers returns the launchers which are only available on Linux, of which there are none.
+func platformLaunchers() map[string]func() Launcher {
+	return nil
+}
@@ -0,0 +1,11 @@
+package rdp
+
+// defaultLauncher returns the Mstsc launcher.
+func defaultLauncher() (Launcher, error) {
+	return MstscLauncher(), nil
+}
+
+// platformLaunchers returns the launchers which are only available on Windows.
+func platformLaunchers() map[string]func() Launcher {
+	return map[string]func() Launcher{"mstsc": MstscLauncher}
+}
@@ -0,0 +1,235 @@
+package rdp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Screen mode values for the 'screen mode id' .rdp file setting.
+const (
+	screenModeWindow     = 1
+	screenModeFullscreen = 2
+)
+
+// FileBody returns the contents of a .rdp file which connects with the given parameters. The password is never written.
+func FileBody(rdp *RDP) string {
+	screenMode := screenModeWindow
+	if rdp.Fullscreen {
+		screenMode = screenModeFullscreen
+	}
+
+	lines := []string{
+		fmt.Sprintf("screen mode id:i:%d", screenMode),
+		"auto connect:i:1",
+		"prompt for credentials:i:0",
+		fmt.Sprintf("full address:s:%s", socket(rdp.Address, rdp.Port)),
+	}
+
+	if rdp.Username != "" {
+		lines = append(lines, fmt.Sprintf("username:s:%s", rdp.Username))
+	}
+	if rdp.Width != 0 {
+		lines = append(lines, fmt.Sprintf("desktopwidth:i:%d", rdp.Width))
+	}
+	if rdp.Height != 0 {
+		lines = append(lines, fmt.Sprintf("desktopheight:i:%d", rdp.Height))
+	}
+	if rdp.Span {
+		lines = append(lines, "span monitors:i:1")
+	}
+
+	lines = append(lines, rdp.Properties.lines()...)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// lines returns a .rdp file line for each property which is set.
+func (p *Properties) lines() []string {
+	lines := make([]string, 0)
+
+	values := reflect.ValueOf(p).Elem()
+	structType := values.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		name, kind := propertyTag(structType.Field(i))
+		v := values.Field(i)
+
+		switch v.Kind() {
+		case reflect.String:
+			if v.String() != "" {
+				lines = append(lines, fmt.Sprintf("%s:%s:%s", name, kind, v.String()))
+			}
+
+		case reflect.Ptr:
+			if v.IsNil() {
+				continue
+			}
+
+			switch e := v.Elem(); e.Kind() {
+			case reflect.Bool:
+				lines = append(lines, fmt.Sprintf("%s:%s:%d", name, kind, boolToInt(e.Bool())))
+			case reflect.Int:
+				lines = append(lines, fmt.Sprintf("%s:%s:%d", name, kind, e.Int()))
+			}
+		}
+	}
+
+	return lines
+}
+
+// ParseFile reads a .rdp file into an RDP struct. Files saved by mstsc are UTF-16 encoded and are converted. The names
+// of any settings which are not supported are returned.
+func ParseFile(r io.Reader) (*RDP, []string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading rdp file: %w", err)
+	}
+
+	rdp := &RDP{}
+	unsupported := make([]string, 0)
+
+	properties := rdp.Properties.byTag()
+
+	scanner := bufio.NewScanner(bytes.NewReader(decodeUTF16(data)))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			return nil, nil, fmt.Errorf("line %d: expected '<name>:<type>:<value>': got '%s'", lineNumber, line)
+		}
+
+		name, kind, value := strings.ToLower(parts[0]), parts[1], parts[2]
+
+		var intValue int
+		if kind == "i" {
+			if intValue, err = strconv.Atoi(value); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %s value '%s' is not an integer", lineNumber, name, value)
+			}
+		}
+
+		switch name {
+		case "full address":
+			rdp.Address, rdp.Port = splitSocket(value)
+		case "server port":
+			rdp.Port = value
+		case "username":
+			rdp.Username = value
+		case "desktopwidth":
+			rdp.Width = intValue
+		case "desktopheight":
+			rdp.Height = intValue
+		case "screen mode id":
+			rdp.Fullscreen = intValue == screenModeFullscreen
+		case "span monitors":
+			rdp.Span = intValue == 1
+		case "auto connect", "prompt for credentials":
+			// Always written by FileBody
+		default:
+			field, ok := properties[name]
+			if !ok {
+				unsupported = append(unsupported, name)
+				continue
+			}
+
+			switch field.Kind() {
+			case reflect.String:
+				field.SetString(value)
+			case reflect.Ptr:
+				ptr := reflect.New(field.Type().Elem())
+				if ptr.Elem().Kind() == reflect.Bool {
+					ptr.Elem().SetBool(intValue != 0)
+				} else {
+					ptr.Elem().SetInt(int64(intValue))
+				}
+				field.Set(ptr)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading rdp file: %w", err)
+	}
+
+	return rdp, unsupported, nil
+}
+
+// byTag returns the fields of p mapped to the .rdp file setting names in their tags.
+func (p *Properties) byTag() map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+
+	values := reflect.ValueOf(p).Elem()
+	structType := values.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		name, _ := propertyTag(structType.Field(i))
+		fields[name] = values.Field(i)
+	}
+
+	return fields
+}
+
+// decodeUTF16 converts UTF-16 text with a byte order mark to UTF-8. Any other data is returned unchanged.
+func decodeUTF16(data []byte) []byte {
+	if len(data) < 2 {
+		return data
+	}
+
+	var littleEndian bool
+	switch {
+	case data[0] == 0xFF && data[1] == 0xFE:
+		littleEndian = true
+	case data[0] == 0xFE && data[1] == 0xFF:
+		littleEndian = false
+	default:
+		return bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	}
+
+	data = data[2:]
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if littleEndian {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		} else {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		}
+	}
+
+	return []byte(string(utf16.Decode(units)))
+}
+
+// splitSocket splits an address with an optional port.
+func splitSocket(s string) (string, string) {
+	if host, port, err := net.SplitHostPort(s); err == nil {
+		return host, port
+	}
+
+	return s, ""
+}
+
+// propertyTag returns the .rdp file setting name and type from the 'rdp' struct tag of a Properties field.
+func propertyTag(f reflect.StructField) (string, string) {
+	tag := f.Tag.Get("rdp")
+	i := strings.LastIndex(tag, ":")
+
+	return tag[:i], tag[i+1:]
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,123 @@
+package rdp
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestFileBody(t *testing.T) {
+	no, scale, audio := false, 125, 1
+
+	got := FileBody(&RDP{
+		Username: "user", Password: "secretpassword",
+		Address: "1.2.3.4", Port: "3390",
+		Width: 800, Height: 600,
+		Fullscreen: true, Span: true,
+		Properties: Properties{
+			Domain:            "CORP",
+			RedirectClipboard: &no,
+			ScaleFactor:       &scale,
+			AudioMode:         &audio,
+			DrivesToRedirect:  "*",
+			GatewayHostname:   "gateway.example.com",
+		},
+	})
+
+	for _, want := range []string{
+		"screen mode id:i:2",
+		"full address:s:1.2.3.4:3390",
+		"username:s:user",
+		"desktopwidth:i:800",
+		"desktopheight:i:600",
+		"span monitors:i:1",
+		"domain:s:CORP",
+		"redirectclipboard:i:0",
+		"desktopscalefactor:i:125",
+		"audiomode:i:1",
+		"drivestoredirect:s:*",
+		"gatewayhostname:s:gateway.example.com",
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("rdp file is missing line '%s':\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "secretpassword") {
+		t.Errorf("password was written to the rdp file")
+	}
+
+	// Unset properties are not written
+	got = FileBody(&RDP{Address: "1.2.3.4"})
+
+	for _, unwanted := range []string{"redirectclipboard", "desktopscalefactor", "domain", "username", "desktopwidth"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("rdp file contains unset property '%s':\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestProperties_Validate(t *testing.T) {
+	valid, invalidScale, invalidLevel, yes := 150, 120, 4, true
+
+	if err := (Properties{ScaleFactor: &valid}).Validate(); err != nil {
+		t.Errorf("unexpected error returned for valid properties: %s", err)
+	}
+
+	for _, p := range []Properties{
+		{ScaleFactor: &invalidScale},
+		{AuthenticationLevel: &invalidLevel},
+		{RemoteApplicationMode: &yes},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("no error returned for invalid properties: %+v", p)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	text := "screen mode id:i:2\r\nfull address:s:myhost.example.com:3390\r\nusername:s:CORP\\user\r\n" +
+		"desktopwidth:i:1024\r\ndesktopheight:i:768\r\nredirectclipboard:i:0\r\naudiomode:i:2\r\n" +
+		"drivestoredirect:s:*\r\nwinposstr:s:0,1,0,0,800,600\r\n"
+
+	// Files saved by mstsc are UTF-16 little endian with a byte order mark
+	encoded := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(text)) {
+		encoded = append(encoded, byte(u), byte(u>>8))
+	}
+
+	for _, data := range [][]byte{[]byte(text), encoded} {
+		r, unsupported, err := ParseFile(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error returned: %s", err)
+		}
+
+		if r.Address != "myhost.example.com" || r.Port != "3390" {
+			t.Errorf("unexpected socket: want myhost.example.com:3390: got %s:%s", r.Address, r.Port)
+		}
+		if r.Username != `CORP\user` {
+			t.Errorf("unexpected username: %s", r.Username)
+		}
+		if r.Width != 1024 || r.Height != 768 || !r.Fullscreen {
+			t.Errorf("unexpected display settings: %dx%d fullscreen %t", r.Width, r.Height, r.Fullscreen)
+		}
+		if r.RedirectClipboard == nil || *r.RedirectClipboard {
+			t.Errorf("redirectclipboard was not parsed as false")
+		}
+		if r.AudioMode == nil || *r.AudioMode != 2 {
+			t.Errorf("audiomode was not parsed as 2")
+		}
+		if r.DrivesToRedirect != "*" {
+			t.Errorf("unexpected drivestoredirect: %s", r.DrivesToRedirect)
+		}
+		if !reflect.DeepEqual(unsupported, []string{"winposstr"}) {
+			t.Errorf("unexpected unsupported settings: %s", unsupported)
+		}
+	}
+
+	if _, _, err := ParseFile(strings.NewReader("desktopwidth:i:abc")); err == nil {
+		t.Errorf("no error returned for an invalid integer value")
+	}
+}
@@ -0,0 +1,99 @@
+package rdp
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// XFreeRDPLauncher returns a struct of type rdp.FreeRDP which runs xfreerdp.
+func XFreeRDPLauncher() Launcher {
+	return &FreeRDP{Executable: XFreeRDP}
+}
+
+// WLFreeRDPLauncher returns a struct of type rdp.FreeRDP which runs wlfreerdp.
+func WLFreeRDPLauncher() Launcher {
+	return &FreeRDP{Executable: WLFreeRDP}
+}
+
+// FreeRDP implements Launcher and starts sessions with one of the FreeRDP clients.
+type FreeRDP struct {
+	Executable string
+}
+
+// Launch runs the FreeRDP client with the session parameters as arguments.
+func (f *FreeRDP) Launch(rdp *RDP, debug bool) error {
+	startSession := f.command(rdp)
+
+	if debug {
+		fmt.Println(redact(startSession.String(), rdp.Password))
+	}
+	if err := startSession.Run(); err != nil {
+		return fmt.Errorf("running rdp session using %s: %w", f.Executable, err)
+	}
+
+	return nil
+}
+
+// command returns the FreeRDP command. The password is written to its stdin rather than passed as an argument, where
+// other local users could read it from the process list.
+func (f *FreeRDP) command(rdp *RDP) *exec.Cmd {
+	cmd := exec.Command(f.Executable, FreeRDPArgs(rdp)...)
+
+	if rdp.Password != "" {
+		cmd.Stdin = strings.NewReader(FreeRDPStdin(rdp))
+	}
+
+	return cmd
+}
+
+// FreeRDPStdin returns the input for the credential prompts FreeRDP shows when it is run with /from-stdin:force. The
+// domain is only prompted for when there is no username, because FreeRDP sets an empty domain when it parses /u.
+func FreeRDPStdin(rdp *RDP) string {
+	if rdp.Username == "" {
+		return "\n\n" + rdp.Password + "\n"
+	}
+
+	return rdp.Password + "\n"
+}
+
+// FreeRDPArgs returns the command line arguments for xfreerdp or wlfreerdp which will start a session with the given
+// parameters. If there is a password, FreeRDP is told to read it from stdin (see FreeRDPStdin).
+func FreeRDPArgs(rdp *RDP) []string {
+	args := []string{
+		fmt.Sprintf("/v:%s", socket(rdp.Address, rdp.Port)),
+	}
+
+	if rdp.Username != "" {
+		args = append(args, fmt.Sprintf("/u:%s", rdp.Username))
+	}
+	if rdp.Password != "" {
+		args = append(args, "/from-stdin:force")
+	}
+
+	if rdp.Width != 0 {
+		args = append(args, fmt.Sprintf("/w:%d", rdp.Width))
+	}
+	if rdp.Height != 0 {
+		args = append(args, fmt.Sprintf("/h:%d", rdp.Height))
+	}
+
+	if rdp.Fullscreen {
+		args = append(args, "/f")
+	}
+	if rdp.Span {
+		args = append(args, "/span")
+	}
+
+	return args
+}
+
+// socket joins an address and port, omitting the port if it is empty.
+func socket(address, port string) string {
+	if port == "" {
+		return address
+	}
+
+	return net.JoinHostPort(address, port)
+}
@@ -0,0 +1,63 @@
+package rdp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFreeRDPArgs(t *testing.T) {
+	got := FreeRDPArgs(&RDP{
+		Username: "user", Password: "pass",
+		Address: "1.2.3.4", Port: "3390",
+		Width: 800, Height: 600,
+		Fullscreen: true, Span: true,
+	})
+
+	want := []string{"/v:1.2.3.4:3390", "/u:user", "/from-stdin:force", "/w:800", "/h:600", "/f", "/span"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected arguments: want %s: got %s", want, got)
+	}
+
+	got = FreeRDPArgs(&RDP{Address: "myhost"})
+	want = []string{"/v:myhost"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected arguments for address only: want %s: got %s", want, got)
+	}
+}
+
+func TestFreeRDPStdin(t *testing.T) {
+	if got := FreeRDPStdin(&RDP{Username: "user", Password: "pass"}); got != "pass\n" {
+		t.Errorf("unexpected stdin with a username: %q", got)
+	}
+
+	if got := FreeRDPStdin(&RDP{Password: "pass"}); got != "\n\npass\n" {
+		t.Errorf("unexpected stdin without a username: %q", got)
+	}
+}
+
+func TestFreeRDP_Command(t *testing.T) {
+	for _, launcherFunc := range []func() Launcher{XFreeRDPLauncher, WLFreeRDPLauncher} {
+		f := launcherFunc().(*FreeRDP)
+
+		got := f.command(&RDP{Address: "1.2.3.4"}).Args
+		want := []string{f.Executable, "/v:1.2.3.4"}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("unexpected command: want %s: got %s", want, got)
+		}
+
+		cmd := f.command(&RDP{Address: "1.2.3.4", Username: "user", Password: "secret"})
+		for _, arg := range cmd.Args {
+			if strings.Contains(arg, "secret") {
+				t.Errorf("password was passed as an argument: %s", cmd.Args)
+			}
+		}
+
+		if cmd.Stdin == nil {
+			t.Errorf("expected the password to be written to stdin")
+		}
+	}
+}
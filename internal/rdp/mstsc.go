@@ -0,0 +1,104 @@
+package rdp
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// MstscLauncher returns a struct of type rdp.Mstsc.
+func MstscLauncher() Launcher {
+	return &Mstsc{}
+}
+
+// Mstsc implements Launcher and starts sessions with the Windows Remote Desktop Connection client. Credentials are
+// stored with cmdkey and the .rdp file settings are written to a temporary file for the duration of the session.
+type Mstsc struct{}
+
+// Launch stores any credentials with cmdkey, writes an RDP file and runs mstsc with it, deleting the credentials and
+// the file when the session ends.
+func (m *Mstsc) Launch(rdp *RDP, debug bool) error {
+	path, err := writeTempFile(FileBody(rdp), ".rdp")
+	if err != nil {
+		return fmt.Errorf("writing rdp file: %w", err)
+	}
+	defer deleteFile(path)
+
+	cmdkeyCreate, cmdkeyDelete := m.cmdkeyCommands(rdp)
+
+	if cmdkeyCreate != nil {
+		if debug {
+			fmt.Println(redact(cmdkeyCreate.String(), rdp.Password))
+		}
+		if err := cmdkeyCreate.Run(); err != nil {
+			return fmt.Errorf("creating credentials using cmdkey.exe: %w", err)
+		}
+
+		defer func() {
+			if debug {
+				fmt.Println(cmdkeyDelete.String())
+			}
+			if err := cmdkeyDelete.Run(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	startSession := m.command(rdp, path)
+
+	if debug {
+		fmt.Println(startSession.String())
+	}
+	if err := startSession.Run(); err != nil {
+		return fmt.Errorf("running rdp session using mstsc.exe: %w", err)
+	}
+
+	return nil
+}
+
+// cmdkeyCommands returns commands which create and delete credentials for the session. Both are nil if no username is
+// given.
+func (m *Mstsc) cmdkeyCommands(rdp *RDP) (*exec.Cmd, *exec.Cmd) {
+	if rdp.Username == "" {
+		return nil, nil
+	}
+
+	cmdkeyCreateArgs := []string{
+		fmt.Sprintf("/generic:%s", rdp.Address),
+		fmt.Sprintf("/user:%s", rdp.Username),
+	}
+
+	if rdp.Password != "" {
+		cmdkeyCreateArgs = append(cmdkeyCreateArgs, fmt.Sprintf("/pass:%s", rdp.Password))
+	}
+
+	return exec.Command("cmdkey", cmdkeyCreateArgs...),
+		exec.Command("cmdkey", fmt.Sprintf("/delete:%s", rdp.Address))
+}
+
+// command returns the mstsc command for the session. Arguments take precedence over the settings in the RDP file at
+// the given path.
+func (m *Mstsc) command(rdp *RDP, path string) *exec.Cmd {
+	mstscArgs := []string{
+		path,
+		fmt.Sprintf("/v:%s:%s", rdp.Address, rdp.Port),
+	}
+
+	if rdp.Width != 0 {
+		mstscArgs = append(mstscArgs, fmt.Sprintf("/w:%d", rdp.Width))
+	}
+	if rdp.Height != 0 {
+		mstscArgs = append(mstscArgs, fmt.Sprintf("/h:%d", rdp.Height))
+	}
+
+	if rdp.Fullscreen {
+		mstscArgs = append(mstscArgs, "/f")
+	}
+	if rdp.Public {
+		mstscArgs = append(mstscArgs, "/public")
+	}
+	if rdp.Span {
+		mstscArgs = append(mstscArgs, "/span")
+	}
+
+	return exec.Command("mstsc", mstscArgs...)
+}
@@ -0,0 +1,44 @@
+package rdp
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestMstsc_Commands(t *testing.T) {
+	m := &Mstsc{}
+	r := &RDP{
+		Username: "user", Password: "pass",
+		Address: "1.2.3.4", Port: "3390",
+		Width: 800, Height: 600,
+		Fullscreen: true, Span: true,
+	}
+
+	create, remove := m.cmdkeyCommands(r)
+
+	want := []string{"cmdkey", "/generic:1.2.3.4", "/user:user", "/pass:pass"}
+	if !reflect.DeepEqual(create.Args, want) {
+		t.Errorf("unexpected cmdkey create arguments: want %s: got %s", want, create.Args)
+	}
+
+	want = []string{"cmdkey", "/delete:1.2.3.4"}
+	if !reflect.DeepEqual(remove.Args, want) {
+		t.Errorf("unexpected cmdkey delete arguments: want %s: got %s", want, remove.Args)
+	}
+
+	want = []string{"mstsc", "connection.rdp", "/v:1.2.3.4:3390", "/w:800", "/h:600", "/f", "/span"}
+	if got := m.command(r, "connection.rdp").Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected mstsc arguments: want %s: got %s", want, got)
+	}
+
+	if create, remove = m.cmdkeyCommands(&RDP{Address: "1.2.3.4"}); create != nil || remove != nil {
+		t.Errorf("cmdkey commands were returned when no username was given")
+	}
+}
+
+func TestMstsc_Registered(t *testing.T) {
+	if _, ok := Launchers["mstsc"]; ok != (runtime.GOOS == "windows") {
+		t.Errorf("unexpected mstsc launcher on %s: registered is %t", runtime.GOOS, ok)
+	}
+}
@@ -0,0 +1,27 @@
+package rdp
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// PrintLauncher returns a struct of type rdp.Print which writes to stdout.
+func PrintLauncher() Launcher {
+	return &Print{Out: os.Stdout}
+}
+
+// Print implements Launcher by writing the .rdp file for the session to Out instead of starting a client. The
+// password is never written.
+type Print struct {
+	Out io.Writer
+}
+
+// Launch writes the .rdp file body to Out.
+func (p *Print) Launch(rdp *RDP, _ bool) error {
+	if _, err := fmt.Fprint(p.Out, FileBody(rdp)); err != nil {
+		return fmt.Errorf("printing rdp file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package rdp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrint_Launch(t *testing.T) {
+	var buf bytes.Buffer
+	p := &Print{Out: &buf}
+
+	err := p.Launch(&RDP{Username: "user", Password: "secretpassword", Address: "1.2.3.4"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	got := buf.String()
+
+	if !strings.Contains(got, "full address:s:1.2.3.4") {
+		t.Errorf("output is missing the address:\n%s", got)
+	}
+
+	if strings.Contains(got, "secretpassword") {
+		t.Errorf("password was printed")
+	}
+}
+
+func TestConnect(t *testing.T) {
+	if err := Connect(&RDP{Address: "1.2.3.4"}, "notaclient", false); err == nil {
+		t.Errorf("no error returned for an unknown client name")
+	}
+
+	if err := Connect(&RDP{}, "print", false); err == nil {
+		t.Errorf("no error returned for an empty address")
+	}
+}
@@ -0,0 +1,113 @@
+package rdp
+
+import (
+	"fmt"
+)
+
+// Properties are optional .rdp file settings. Each field is tagged with the name and type of the .rdp file property it
+// sets. Nil pointers and empty strings are not written, leaving the client default in place.
+// https://docs.microsoft.com/en-us/windows-server/remote/remote-desktop-services/clients/rdp-files
+type Properties struct {
+	// Connection
+	Domain                string `rdp:"domain:s"`
+	AdminSession          *bool  `rdp:"administrative session:i"`
+	AlternateShell        string `rdp:"alternate shell:s"`
+	ShellWorkingDirectory string `rdp:"shell working directory:s"`
+	AutoReconnect         *bool  `rdp:"autoreconnection enabled:i"`
+	AuthenticationLevel   *int   `rdp:"authentication level:i"`
+	EnableCredSSP         *bool  `rdp:"enablecredsspsupport:i"`
+	LoadBalanceInfo       string `rdp:"loadbalanceinfo:s"`
+
+	// Remote Desktop Gateway
+	GatewayHostname           string `rdp:"gatewayhostname:s"`
+	GatewayUsageMethod        *int   `rdp:"gatewayusagemethod:i"`
+	GatewayCredentialsSource  *int   `rdp:"gatewaycredentialssource:i"`
+	GatewayProfileUsageMethod *int   `rdp:"gatewayprofileusagemethod:i"`
+	PromptCredentialOnce      *bool  `rdp:"promptcredentialonce:i"`
+
+	// Display
+	UseMultimon               *bool  `rdp:"use multimon:i"`
+	SelectedMonitors          string `rdp:"selectedmonitors:s"`
+	MaximizeToCurrentDisplays *bool  `rdp:"maximizetocurrentdisplays:i"`
+	SingleMonInWindowedMode   *bool  `rdp:"singlemoninwindowedmode:i"`
+	SmartSizing               *bool  `rdp:"smart sizing:i"`
+	DynamicResolution         *bool  `rdp:"dynamic resolution:i"`
+	ScaleFactor               *int   `rdp:"desktopscalefactor:i"`
+	SessionBPP                *int   `rdp:"session bpp:i"`
+
+	// Device redirection
+	AudioMode            *int   `rdp:"audiomode:i"`
+	AudioCaptureMode     *bool  `rdp:"audiocapturemode:i"`
+	RedirectClipboard    *bool  `rdp:"redirectclipboard:i"`
+	RedirectPrinters     *bool  `rdp:"redirectprinters:i"`
+	RedirectSmartcards   *bool  `rdp:"redirectsmartcards:i"`
+	RedirectCOMPorts     *bool  `rdp:"redirectcomports:i"`
+	RedirectLocation     *bool  `rdp:"redirectlocation:i"`
+	RedirectWebAuthn     *bool  `rdp:"redirectwebauthn:i"`
+	DrivesToRedirect     string `rdp:"drivestoredirect:s"`
+	CamerasToRedirect    string `rdp:"camerastoredirect:s"`
+	DevicesToRedirect    string `rdp:"devicestoredirect:s"`
+	USBDevicesToRedirect string `rdp:"usbdevicestoredirect:s"`
+	KeyboardHook         *int   `rdp:"keyboardhook:i"`
+
+	// Performance
+	Compression         *bool `rdp:"compression:i"`
+	VideoPlaybackMode   *bool `rdp:"videoplaybackmode:i"`
+	ConnectionType      *int  `rdp:"connection type:i"`
+	NetworkAutoDetect   *bool `rdp:"networkautodetect:i"`
+	BandwidthAutoDetect *bool `rdp:"bandwidthautodetect:i"`
+
+	// RemoteApp
+	RemoteApplicationMode    *bool  `rdp:"remoteapplicationmode:i"`
+	RemoteApplicationProgram string `rdp:"remoteapplicationprogram:s"`
+	RemoteApplicationName    string `rdp:"remoteapplicationname:s"`
+	RemoteApplicationCmdLine string `rdp:"remoteapplicationcmdline:s"`
+}
+
+// Validate returns an error if a property has a value which is not valid for its .rdp file setting.
+func (p Properties) Validate() error {
+	ranges := []struct {
+		name     string
+		value    *int
+		min, max int
+	}{
+		{"authenticationlevel", p.AuthenticationLevel, 0, 3},
+		{"gatewayusagemethod", p.GatewayUsageMethod, 0, 4},
+		{"gatewaycredentialssource", p.GatewayCredentialsSource, 0, 5},
+		{"gatewayprofileusagemethod", p.GatewayProfileUsageMethod, 0, 1},
+		{"audiomode", p.AudioMode, 0, 2},
+		{"keyboardhook", p.KeyboardHook, 0, 2},
+		{"connectiontype", p.ConnectionType, 1, 7},
+	}
+
+	for _, r := range ranges {
+		if r.value != nil && (*r.value < r.min || *r.value > r.max) {
+			return fmt.Errorf("%s value %d is invalid, must be between %d and %d", r.name, *r.value, r.min, r.max)
+		}
+	}
+
+	if p.ScaleFactor != nil && !intIn(*p.ScaleFactor, 100, 125, 150, 175, 200, 250, 300, 400, 500) {
+		return fmt.Errorf("scalefactor value %d is invalid, must be one of 100, 125, 150, 175, 200, 250, 300, 400, 500",
+			*p.ScaleFactor)
+	}
+
+	if p.SessionBPP != nil && !intIn(*p.SessionBPP, 15, 16, 24, 32) {
+		return fmt.Errorf("sessionbpp value %d is invalid, must be one of 15, 16, 24, 32", *p.SessionBPP)
+	}
+
+	if p.RemoteApplicationMode != nil && *p.RemoteApplicationMode && p.RemoteApplicationProgram == "" {
+		return fmt.Errorf("remoteapplicationprogram must be set when remoteapplicationmode is true")
+	}
+
+	return nil
+}
+
+func intIn(v int, values ...int) bool {
+	for _, i := range values {
+		if v == i {
+			return true
+		}
+	}
+
+	return false
+}
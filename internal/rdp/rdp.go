@@ -1,6 +1,10 @@
 package rdp
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // DefaultPort is the standard port for RDP connections.
 const DefaultPort = "3389"
@@ -10,6 +14,8 @@ type RDP struct {
 	Address, Port            string
 	Width, Height            int
 	Fullscreen, Public, Span bool
+
+	Properties
 }
 
 func (r RDP) String() string {
@@ -29,3 +35,91 @@ Span: %t`,
 		r.Span,
 	)
 }
+
+// Launcher starts an RDP session using a specific client application.
+type Launcher interface {
+	Launch(rdp *RDP, debug bool) error
+}
+
+// Launchers is the source of truth for a complete list of implemented client names and launcher functions. It
+// includes the launchers which are only available on this platform.
+var Launchers = launchers()
+
+func launchers() map[string]func() Launcher {
+	l := map[string]func() Launcher{
+		"rdpfile": RDPFileLauncher,
+		XFreeRDP:  XFreeRDPLauncher,
+		WLFreeRDP: WLFreeRDPLauncher,
+		Remmina:   RemminaLauncher,
+		"print":   PrintLauncher,
+	}
+
+	for k, v := range platformLaunchers() {
+		l[k] = v
+	}
+
+	return l
+}
+
+// LauncherNames returns a sorted slice of all client names in Launchers.
+func LauncherNames() []string {
+	names := make([]string, 0, len(Launchers))
+	for k := range Launchers {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Connect starts a session using the launcher with the given client name. If client is an empty string the default
+// launcher for this platform is used.
+func Connect(rdp *RDP, client string, debug bool) error {
+	if rdp.Address == "" {
+		return fmt.Errorf("address is an empty string, nothing to connect to")
+	}
+
+	l, err := launcher(client)
+	if err != nil {
+		return err
+	}
+
+	return l.Launch(rdp, debug)
+}
+
+// Serial returns true if sessions using the launcher with the given client name must be started one at a time.
+// RDPFile copies the password of each session to the clipboard, so starting another session would replace it.
+func Serial(client string) bool {
+	l, err := launcher(client)
+	if err != nil {
+		return false
+	}
+
+	_, ok := l.(*RDPFile)
+
+	return ok
+}
+
+// launcher returns the launcher with the given client name, or the default launcher for this platform if client is an
+// empty string.
+func launcher(client string) (Launcher, error) {
+	if client == "" {
+		return defaultLauncher()
+	}
+
+	launcherFunc, ok := Launchers[client]
+	if !ok {
+		return nil, fmt.Errorf("client '%s' is not one of %s", client, strings.Join(LauncherNames(), ", "))
+	}
+
+	return launcherFunc(), nil
+}
+
+func redact(s, secret string) string {
+	if secret == "" {
+		return s
+	}
+
+	return strings.Replace(s, secret, "REMOVED", -1)
+}
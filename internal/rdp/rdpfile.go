@@ -0,0 +1,88 @@
+package rdp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/atotto/clipboard"
+	"github.com/skratchdot/open-golang/open"
+)
+
+// RDPFileLauncher returns a struct of type rdp.RDPFile.
+func RDPFileLauncher() Launcher {
+	return &RDPFile{}
+}
+
+// RDPFile implements Launcher by opening a temporary .rdp file with the application associated with it, which is
+// Microsoft Remote Desktop on macOS. The password can't be written to the file so it is copied to the clipboard.
+type RDPFile struct{}
+
+// Launch writes an RDP file, runs it then deletes it 1 second later.
+func (f *RDPFile) Launch(rdp *RDP, debug bool) error {
+	fb := FileBody(rdp)
+
+	if rdp.Password != "" {
+		fmt.Println("WARNING: Writing secret to clipboard - be careful where you paste!")
+
+		if err := clipboard.WriteAll(rdp.Password); err != nil {
+			return fmt.Errorf("writing password to clipboard: %w", err)
+		}
+	}
+
+	path, err := writeTempFile(fb, ".rdp")
+	if err != nil {
+		return fmt.Errorf("writing rdp file: %w", err)
+	}
+
+	if debug {
+		fmt.Printf("wrote %s:\n%s", path, fb)
+	}
+
+	runRDPFile(path)
+	// Ensure the file is deleted. Wait for 1 second before deleting it to allow the RDP application to read it.
+	defer deleteFile(path)
+	time.Sleep(1 * time.Second)
+
+	return nil
+}
+
+func deleteFile(path string) {
+	_ = os.Remove(path)
+}
+
+// writeTempFile writes body to a new file with the given extension and returns its path. The file is created in the
+// directory of the tempfile-path setting and named after it, with a random suffix so that sessions which are started
+// at the same time each have their own file.
+func writeTempFile(body, ext string) (string, error) {
+	tempPath := viper.GetString("tempfile-path")
+	prefix := strings.TrimSuffix(filepath.Base(tempPath), filepath.Ext(tempPath))
+
+	f, err := ioutil.TempFile(filepath.Dir(tempPath), prefix+"-*"+ext)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.WriteString(body); err != nil {
+		_ = f.Close()
+		deleteFile(f.Name())
+
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		deleteFile(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+func runRDPFile(runPath string) {
+	_ = open.Run(runPath)
+}
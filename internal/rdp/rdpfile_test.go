@@ -0,0 +1,54 @@
+package rdp
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWriteTempFile(t *testing.T) {
+	dir := t.TempDir()
+
+	viper.Set("tempfile-path", filepath.Join(dir, "connection.rdp"))
+	t.Cleanup(func() { viper.Set("tempfile-path", "") })
+
+	paths := make(map[string]bool)
+
+	for _, body := range []string{"first", "second"} {
+		path, err := writeTempFile(body, ".remmina")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "connection-") ||
+			filepath.Ext(path) != ".remmina" {
+			t.Errorf("unexpected temporary file path %s", path)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil || string(data) != body {
+			t.Errorf("unexpected contents of %s: want %q: got %q, %v", path, body, data, err)
+		}
+
+		paths[path] = true
+	}
+
+	if len(paths) != 2 {
+		t.Errorf("sessions share a temporary file: %v", paths)
+	}
+}
+
+func TestSerial(t *testing.T) {
+	if !Serial("rdpfile") {
+		t.Errorf("rdpfile sessions are not started one at a time")
+	}
+
+	for _, client := range []string{"print", Remmina, "notaclient"} {
+		if Serial(client) {
+			t.Errorf("%s sessions are started one at a time", client)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package rdp
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Remmina view modes as defined in remmina's profile format.
+const (
+	remminaWindowMode     = 1
+	remminaFullscreenMode = 4
+)
+
+// RemminaLauncher returns a struct of type rdp.RemminaClient.
+func RemminaLauncher() Launcher {
+	return &RemminaClient{}
+}
+
+// RemminaClient implements Launcher and starts sessions with Remmina using a temporary connection profile.
+type RemminaClient struct{}
+
+// Launch writes a temporary Remmina profile, starts Remmina with it and deletes it 1 second later.
+func (r *RemminaClient) Launch(rdp *RDP, debug bool) error {
+	profile, err := writeTempFile(RemminaProfile(rdp), ".remmina")
+	if err != nil {
+		return fmt.Errorf("writing remmina profile: %w", err)
+	}
+	defer deleteFile(profile)
+
+	startSession := r.command(profile)
+
+	if debug {
+		fmt.Println(startSession.String())
+	}
+	if err := startSession.Start(); err != nil {
+		return fmt.Errorf("running rdp session using %s: %w", Remmina, err)
+	}
+
+	// Wait for 1 second before deleting the profile to allow remmina to read it.
+	time.Sleep(1 * time.Second)
+	deleteFile(profile)
+
+	if err := startSession.Wait(); err != nil {
+		return fmt.Errorf("running rdp session using %s: %w", Remmina, err)
+	}
+
+	return nil
+}
+
+func (r *RemminaClient) command(profile string) *exec.Cmd {
+	return exec.Command(Remmina, "-c", profile)
+}
+
+// RemminaProfile returns the body of a .remmina connection profile for the given parameters.
+//
+// Remmina only reads passwords from a profile if they were encrypted with its own local secret, so the password is
+// never written and Remmina will prompt for it.
+func RemminaProfile(rdp *RDP) string {
+	lines := []string{
+		"[remmina]",
+		"protocol=RDP",
+		fmt.Sprintf("server=%s", socket(rdp.Address, rdp.Port)),
+	}
+
+	if rdp.Username != "" {
+		lines = append(lines, fmt.Sprintf("username=%s", rdp.Username))
+	}
+
+	if rdp.Width != 0 && rdp.Height != 0 {
+		lines = append(lines,
+			"resolution_mode=2",
+			fmt.Sprintf("resolution_width=%d", rdp.Width),
+			fmt.Sprintf("resolution_height=%d", rdp.Height),
+		)
+	}
+
+	viewMode := remminaWindowMode
+	if rdp.Fullscreen {
+		viewMode = remminaFullscreenMode
+	}
+	lines = append(lines, fmt.Sprintf("viewmode=%d", viewMode))
+
+	if rdp.Span {
+		lines = append(lines, "multimon=1")
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,34 @@
+package rdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemminaProfile(t *testing.T) {
+	got := RemminaProfile(&RDP{
+		Username: "user", Password: "secretpassword",
+		Address: "1.2.3.4", Port: "3390",
+		Width: 800, Height: 600,
+		Fullscreen: true, Span: true,
+	})
+
+	for _, want := range []string{
+		"[remmina]",
+		"protocol=RDP",
+		"server=1.2.3.4:3390",
+		"username=user",
+		"resolution_width=800",
+		"resolution_height=600",
+		"viewmode=4",
+		"multimon=1",
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("profile is missing line '%s':\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "secretpassword") {
+		t.Errorf("password was written to the remmina profile")
+	}
+}